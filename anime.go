@@ -0,0 +1,87 @@
+package tlapi
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// AnimeIDs are the ids for an anime across anime databases.
+type AnimeIDs struct {
+	AniDB       int    `json:"anidb,omitempty"`
+	AniList     int    `json:"anilist,omitempty"`
+	MyAnimeList int    `json:"mal,omitempty"`
+	Kitsu       int    `json:"kitsu,omitempty"`
+	TVDB        int    `json:"tvdb,omitempty"`
+	TMDB        int    `json:"tmdb,omitempty"`
+	IMDb        string `json:"imdb,omitempty"`
+}
+
+// AnimeMapping maps AniDB ids to the ids in other anime databases.
+type AnimeMapping struct {
+	m map[int]AnimeIDs
+}
+
+// LoadAnimeMapping loads an anime mapping from the community anime-lists
+// dataset (anime-list-full.json from github.com/Fribb/anime-lists).
+func LoadAnimeMapping(r io.Reader) (*AnimeMapping, error) {
+	var entries []struct {
+		AniDB   jsonString `json:"anidb_id"`
+		AniList jsonString `json:"anilist_id"`
+		MAL     jsonString `json:"mal_id"`
+		Kitsu   jsonString `json:"kitsu_id"`
+		TVDB    jsonString `json:"thetvdb_id"`
+		TMDB    jsonString `json:"themoviedb_id"`
+		IMDb    jsonString `json:"imdb_id"`
+	}
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+	m := make(map[int]AnimeIDs, len(entries))
+	for _, e := range entries {
+		ids := AnimeIDs{
+			AniDB:       atoi(string(e.AniDB)),
+			AniList:     atoi(string(e.AniList)),
+			MyAnimeList: atoi(string(e.MAL)),
+			Kitsu:       atoi(string(e.Kitsu)),
+			TVDB:        atoi(string(e.TVDB)),
+			TMDB:        atoi(string(e.TMDB)),
+			IMDb:        NormalizeIMDbID(string(e.IMDb)),
+		}
+		if ids.AniDB != 0 {
+			m[ids.AniDB] = ids
+		}
+	}
+	return &AnimeMapping{m: m}, nil
+}
+
+// Lookup returns the ids for the AniDB id.
+func (m *AnimeMapping) Lookup(anidbID int) (AnimeIDs, bool) {
+	ids, ok := m.m[anidbID]
+	return ids, ok
+}
+
+// Len returns the number of mapped anime.
+func (m *AnimeMapping) Len() int {
+	return len(m.m)
+}
+
+// AnimeIDs returns the anime ids for the torrent's anime id (an AniDB id),
+// using the mapping.
+func (t Torrent) AnimeIDs(m *AnimeMapping) (AnimeIDs, bool) {
+	id := atoi(strings.TrimSpace(t.AnimeID))
+	if id == 0 || m == nil {
+		return AnimeIDs{}, false
+	}
+	if ids, ok := m.Lookup(id); ok {
+		return ids, true
+	}
+	return AnimeIDs{AniDB: id}, false
+}
+
+// atoi converts a string to an int, returning 0 for invalid values.
+func atoi(s string) int {
+	i, _ := strconv.Atoi(s)
+	return i
+}
@@ -0,0 +1,111 @@
+package tlapi
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ArtworkCache downloads poster and screenshot images through the client,
+// caching them on disk keyed by torrent id.
+type ArtworkCache struct {
+	// Client is the client used to download.
+	Client *Client
+	// Dir is the cache directory.
+	Dir string
+}
+
+// Fetch returns the local paths for the image urls of the torrent id,
+// downloading images not already cached. Relative urls are resolved against
+// the site's base url.
+func (c *ArtworkCache) Fetch(ctx context.Context, id int, urls ...string) ([]string, error) {
+	dir := filepath.Join(c.Dir, strconv.Itoa(id))
+	paths := make([]string, 0, len(urls))
+	for _, u := range urls {
+		p, err := c.fetch(ctx, dir, u)
+		if err != nil {
+			return paths, fmt.Errorf("artwork %d: %s: %w", id, u, err)
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+// Details returns the local paths for the details' poster and images. The
+// poster, when present, is first.
+func (c *ArtworkCache) Details(ctx context.Context, d *Details) ([]string, error) {
+	var urls []string
+	if d.Poster != "" {
+		urls = append(urls, d.Poster)
+	}
+	return c.Fetch(ctx, d.ID, append(urls, d.Images...)...)
+}
+
+// fetch returns the local path for the image url, downloading it when not
+// cached.
+func (c *ArtworkCache) fetch(ctx context.Context, dir, urlstr string) (string, error) {
+	sum := sha1.Sum([]byte(urlstr))
+	key := hex.EncodeToString(sum[:])
+	if matches, _ := filepath.Glob(filepath.Join(dir, key+".*")); len(matches) != 0 {
+		return matches[0], nil
+	}
+	res, err := c.Client.Get(ctx, urlstr)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	typ, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if typ != "" && !strings.HasPrefix(typ, "image/") {
+		return "", fmt.Errorf("unexpected content type %q", typ)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := io.Copy(f, res.Body); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	p := filepath.Join(dir, key+imageExt(res.Request.URL, typ))
+	if err := os.Rename(f.Name(), p); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// imageExts are the known image file extensions.
+var imageExts = map[string]bool{
+	".avif": true,
+	".gif":  true,
+	".jpeg": true,
+	".jpg":  true,
+	".png":  true,
+	".webp": true,
+}
+
+// imageExt returns the file extension for the image url and content type.
+func imageExt(u *url.URL, typ string) string {
+	if ext := strings.ToLower(path.Ext(u.Path)); imageExts[ext] {
+		return ext
+	}
+	if exts, _ := mime.ExtensionsByType(typ); len(exts) != 0 {
+		return exts[0]
+	}
+	return ".img"
+}
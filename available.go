@@ -0,0 +1,59 @@
+package tlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// TorrentHeaderInfo is torrent download information, read from the download
+// response headers.
+type TorrentHeaderInfo struct {
+	Filename    string
+	Size        int64
+	ContentType string
+}
+
+// TorrentAvailable checks if the torrent id's metainfo can be downloaded,
+// using a HEAD request (or a GET request, closed before reading the body,
+// when HEAD is not allowed) to read the size and filename from the response
+// headers. Returns false and no error when the torrent does not exist.
+func (cl *Client) TorrentAvailable(ctx context.Context, id int) (bool, TorrentHeaderInfo, error) {
+	path := fmt.Sprintf("/download/%d/%s", id, "a")
+	var res *http.Response
+	var err error
+	for _, method := range []string{"HEAD", "GET"} {
+		var req *http.Request
+		if req, err = http.NewRequest(method, cl.url(path), nil); err != nil {
+			return false, TorrentHeaderInfo{}, err
+		}
+		res, err = cl.send(ctx, req)
+		var serr *StatusError
+		if !errors.As(err, &serr) || serr.StatusCode != http.StatusMethodNotAllowed {
+			break
+		}
+	}
+	var serr *StatusError
+	switch {
+	case errors.As(err, &serr) && (serr.StatusCode == http.StatusNotFound || serr.StatusCode == http.StatusGone):
+		return false, TorrentHeaderInfo{}, nil
+	case err != nil:
+		return false, TorrentHeaderInfo{}, fmt.Errorf("torrent %d: %w", id, err)
+	}
+	res.Body.Close()
+	info := TorrentHeaderInfo{
+		Size:        res.ContentLength,
+		ContentType: res.Header.Get("Content-Type"),
+	}
+	if strings.Contains(info.ContentType, "text/html") {
+		// missing torrents and captchas are served as html pages
+		return false, info, fmt.Errorf("torrent %d: %w", id, newRequestError(res.Request, &HTMLError{URL: res.Request.URL.Redacted()}))
+	}
+	if _, params, err := mime.ParseMediaType(res.Header.Get("Content-Disposition")); err == nil {
+		info.Filename = params["filename"]
+	}
+	return true, info, nil
+}
@@ -0,0 +1,322 @@
+package tlapi
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// markupNode is a parsed BBCode or html markup node.
+type markupNode struct {
+	tag      string
+	arg      string
+	text     string
+	children []*markupNode
+}
+
+// bbTagRE matches BBCode tags.
+var bbTagRE = regexp.MustCompile(`(?i)\[(/?)(b|i|u|s|url|img|quote|code|list|\*|size|color|colour|font|align|center|left|right|spoiler|hide|youtube|pre|h[1-6])(?:=([^\]]*))?\]`)
+
+// parseBBCode parses BBCode into the node. Unknown tags are left as text,
+// unmatched closing tags are ignored, and unclosed tags are closed at the
+// end of the input.
+func parseBBCode(root *markupNode, s string) {
+	stack := []*markupNode{root}
+	top := func() *markupNode { return stack[len(stack)-1] }
+	addText := func(s string) {
+		if s != "" {
+			top().children = append(top().children, &markupNode{text: s})
+		}
+	}
+	for s != "" {
+		m := bbTagRE.FindStringSubmatchIndex(s)
+		if m == nil {
+			addText(s)
+			break
+		}
+		addText(s[:m[0]])
+		closing, tag := s[m[2]:m[3]] == "/", strings.ToLower(s[m[4]:m[5]])
+		var arg string
+		if m[6] != -1 {
+			arg = strings.Trim(s[m[6]:m[7]], `"'`)
+		}
+		s = s[m[1]:]
+		switch tag {
+		case "colour":
+			tag = "color"
+		case "pre":
+			tag = "code"
+		}
+		if closing {
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].tag == tag {
+					stack = stack[:i]
+					break
+				}
+			}
+			continue
+		}
+		if tag == "*" && top().tag == "*" {
+			stack = stack[:len(stack)-1]
+		}
+		n := &markupNode{tag: tag, arg: arg}
+		top().children = append(top().children, n)
+		if tag == "code" {
+			// code content is not parsed
+			end := strings.Index(strings.ToLower(s), "[/code]")
+			if end == -1 {
+				n.children, s = []*markupNode{{text: s}}, ""
+				continue
+			}
+			n.children = []*markupNode{{text: s[:end]}}
+			s = s[end+len("[/code]"):]
+			continue
+		}
+		stack = append(stack, n)
+	}
+}
+
+// htmlTags are the markup tags for html elements.
+var htmlTags = map[atom.Atom]string{
+	atom.B:          "b",
+	atom.Strong:     "b",
+	atom.I:          "i",
+	atom.Em:         "i",
+	atom.U:          "u",
+	atom.S:          "s",
+	atom.Del:        "s",
+	atom.Strike:     "s",
+	atom.A:          "url",
+	atom.Img:        "img",
+	atom.Blockquote: "quote",
+	atom.Pre:        "code",
+	atom.Code:       "code",
+	atom.Ul:         "list",
+	atom.Ol:         "list",
+	atom.Li:         "*",
+	atom.Br:         "br",
+	atom.P:          "p",
+	atom.Div:        "p",
+	atom.H1:         "h1",
+	atom.H2:         "h2",
+	atom.H3:         "h3",
+	atom.H4:         "h4",
+	atom.H5:         "h5",
+	atom.H6:         "h6",
+}
+
+// parseMarkup parses a description containing html and/or BBCode. BBCode in
+// html text is also parsed.
+func parseMarkup(s string) *markupNode {
+	root := new(markupNode)
+	if !strings.Contains(s, "<") {
+		parseBBCode(root, s)
+		return root
+	}
+	nodes, err := html.ParseFragment(strings.NewReader(s), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "div",
+		DataAtom: atom.Div,
+	})
+	if err != nil {
+		parseBBCode(root, s)
+		return root
+	}
+	for _, n := range nodes {
+		convertHTML(root, n)
+	}
+	return root
+}
+
+// convertHTML converts the html node into markup nodes.
+func convertHTML(parent *markupNode, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		parseBBCode(parent, n.Data)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	switch n.DataAtom {
+	case atom.Script, atom.Style:
+		return
+	}
+	node := parent
+	if tag, ok := htmlTags[n.DataAtom]; ok {
+		node = &markupNode{tag: tag}
+		switch tag {
+		case "url":
+			node.arg = attr(n, "href")
+		case "img":
+			node.children = []*markupNode{{text: attr(n, "src")}}
+			node.arg = attr(n, "alt")
+		}
+		parent.children = append(parent.children, node)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		convertHTML(node, c)
+	}
+}
+
+// markupText returns the node's text content.
+func markupText(n *markupNode) string {
+	if n.text != "" {
+		return n.text
+	}
+	var b strings.Builder
+	for _, c := range n.children {
+		b.WriteString(markupText(c))
+	}
+	return b.String()
+}
+
+// renderMarkup renders the node as plain text, or as Markdown when md is
+// true.
+func renderMarkup(b *strings.Builder, n *markupNode, md bool) {
+	if n.tag == "" && n.text != "" {
+		text := n.text
+		if strings.HasSuffix(b.String(), "\n") {
+			// line break following a block
+			text = strings.TrimPrefix(text, "\n")
+		}
+		if md {
+			text = markdownEscaper.Replace(text)
+		}
+		b.WriteString(text)
+		return
+	}
+	children := func() {
+		for _, c := range n.children {
+			renderMarkup(b, c, md)
+		}
+	}
+	wrap := func(s string) {
+		if md {
+			b.WriteString(s)
+		}
+		children()
+		if md {
+			b.WriteString(s)
+		}
+	}
+	block := func() {
+		if s := b.String(); s != "" && !strings.HasSuffix(s, "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	switch n.tag {
+	case "b":
+		wrap("**")
+	case "i":
+		wrap("_")
+	case "s":
+		wrap("~~")
+	case "url":
+		text := strings.TrimSpace(markupText(n))
+		u := n.arg
+		if u == "" {
+			u = text
+		}
+		switch {
+		case md:
+			if text == "" {
+				text = u
+			}
+			b.WriteString("[" + markdownEscaper.Replace(text) + "](" + u + ")")
+		case text == "" || text == u:
+			b.WriteString(u)
+		default:
+			b.WriteString(text + " (" + u + ")")
+		}
+	case "img":
+		u := strings.TrimSpace(markupText(n))
+		if md {
+			b.WriteString("![" + markdownEscaper.Replace(n.arg) + "](" + u + ")")
+		} else {
+			b.WriteString(u)
+		}
+	case "youtube":
+		u := strings.TrimSpace(markupText(n))
+		if !strings.Contains(u, "/") {
+			u = "https://www.youtube.com/watch?v=" + u
+		}
+		b.WriteString(u)
+	case "code":
+		block()
+		if md {
+			b.WriteString("```\n" + strings.Trim(markupText(n), "\n") + "\n```\n")
+		} else {
+			b.WriteString(strings.Trim(markupText(n), "\n") + "\n")
+		}
+	case "quote":
+		block()
+		var q strings.Builder
+		for _, c := range n.children {
+			renderMarkup(&q, c, md)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(q.String()), "\n") {
+			b.WriteString("> " + line + "\n")
+		}
+	case "*":
+		block()
+		b.WriteString("- ")
+		var item strings.Builder
+		for _, c := range n.children {
+			renderMarkup(&item, c, md)
+		}
+		b.WriteString(strings.TrimSpace(item.String()) + "\n")
+	case "br":
+		b.WriteByte('\n')
+	case "p", "list", "center", "left", "right", "align":
+		block()
+		children()
+		block()
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		block()
+		if md {
+			b.WriteString(strings.Repeat("#", int(n.tag[1]-'0')) + " ")
+		}
+		children()
+		block()
+	default:
+		children()
+	}
+}
+
+// markdownEscaper escapes Markdown special characters.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"[", `\[`,
+	"]", `\]`,
+	"#", `\#`,
+)
+
+// BBCodeText converts a BBCode and/or html description to plain text.
+func BBCodeText(s string) string {
+	return renderDescription(s, false)
+}
+
+// BBCodeMarkdown converts a BBCode and/or html description to Markdown.
+func BBCodeMarkdown(s string) string {
+	return renderDescription(s, true)
+}
+
+// renderDescription renders the description.
+func renderDescription(s string, md bool) string {
+	var b strings.Builder
+	renderMarkup(&b, parseMarkup(strings.ReplaceAll(s, "\r\n", "\n")), md)
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(blankLinesRE.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// blankLinesRE matches runs of blank lines.
+var blankLinesRE = regexp.MustCompile(`\n{3,}`)
@@ -0,0 +1,64 @@
+package tlapi
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// WithMaxBodySize is a TL client option to set the maximum response body
+// size, in bytes, for all requests (including downloads). Responses
+// exceeding it fail with a BodySizeError, either when sent (when the
+// Content-Length is known) or when the body is read. Unlimited when 0.
+func WithMaxBodySize(n int64) Option {
+	return func(cl *Client) {
+		cl.maxBodySize = n
+	}
+}
+
+// BodySizeError is a response body size limit error.
+type BodySizeError struct {
+	Limit int64
+}
+
+// Error satisfies the error interface.
+func (err *BodySizeError) Error() string {
+	return fmt.Sprintf("response body exceeds %d bytes", err.Limit)
+}
+
+// limitBody limits the response body to the client's max body size.
+func (cl *Client) limitBody(res *http.Response) error {
+	if cl.maxBodySize == 0 {
+		return nil
+	}
+	if res.ContentLength > cl.maxBodySize {
+		res.Body.Close()
+		return &BodySizeError{Limit: cl.maxBodySize}
+	}
+	res.Body = &limitedBody{ReadCloser: res.Body, n: cl.maxBodySize, limit: cl.maxBodySize}
+	return nil
+}
+
+// limitedBody is a response body that fails when more than n bytes are
+// read.
+type limitedBody struct {
+	io.ReadCloser
+	n     int64
+	limit int64
+}
+
+// Read satisfies the io.Reader interface.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.n < 0 {
+		return 0, &BodySizeError{Limit: b.limit}
+	}
+	// read one byte past the limit to detect oversized bodies
+	if int64(len(p)) > b.n+1 {
+		p = p[:b.n+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if b.n -= int64(n); b.n < 0 {
+		return n + int(b.n), &BodySizeError{Limit: b.limit}
+	}
+	return n, err
+}
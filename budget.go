@@ -0,0 +1,117 @@
+package tlapi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Budget tracks requests made in a rolling window against a limit, such as
+// the request allowance for an account's user class. A budget is safe for
+// concurrent use, and may be shared by multiple clients using the same
+// account.
+type Budget struct {
+	limit  int
+	window time.Duration
+
+	mu    sync.Mutex
+	times []time.Time
+}
+
+// NewBudget creates a budget allowing limit requests per rolling window.
+func NewBudget(limit int, window time.Duration) *Budget {
+	return &Budget{
+		limit:  limit,
+		window: window,
+	}
+}
+
+// WithBudget is a TL client option to set the request budget. Every request
+// made by the client (including search pagination and downloads) waits for
+// the budget to allow it.
+func WithBudget(b *Budget) Option {
+	return func(cl *Client) {
+		if b != nil {
+			cl.limiter = b
+		}
+	}
+}
+
+// Limit returns the budget's limit and window.
+func (b *Budget) Limit() (int, time.Duration) {
+	return b.limit, b.window
+}
+
+// Remaining returns the number of requests remaining in the current window.
+func (b *Budget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expire(time.Now())
+	return b.limit - len(b.times)
+}
+
+// NextAllowed returns the time the next request is allowed.
+func (b *Budget) NextAllowed() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.expire(now)
+	return b.next(now)
+}
+
+// Take records a request when the budget allows it, returning false
+// otherwise.
+func (b *Budget) Take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.expire(now)
+	if len(b.times) >= b.limit {
+		return false
+	}
+	b.times = append(b.times, now)
+	return true
+}
+
+// Wait waits until the budget allows a request, and records it.
+func (b *Budget) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.expire(now)
+		if len(b.times) < b.limit {
+			b.times = append(b.times, now)
+			b.mu.Unlock()
+			return nil
+		}
+		next := b.next(now)
+		b.mu.Unlock()
+		t := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// expire removes requests that are outside the window.
+func (b *Budget) expire(now time.Time) {
+	i := 0
+	for i < len(b.times) && now.Sub(b.times[i]) >= b.window {
+		i++
+	}
+	b.times = b.times[i:]
+}
+
+// next returns the time the next request is allowed.
+func (b *Budget) next(now time.Time) time.Time {
+	switch {
+	case b.limit <= 0:
+		return now.Add(b.window)
+	case len(b.times) < b.limit:
+		return now
+	}
+	return b.times[len(b.times)-b.limit].Add(b.window)
+}
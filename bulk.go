@@ -0,0 +1,141 @@
+package tlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// bulkWorkers is the number of concurrent lookups made by Torrents.
+const bulkWorkers = 4
+
+// ErrNotListed is the not listed error, returned when a torrent's details
+// page is available, but the torrent is not listed in the search results
+// for its name.
+var ErrNotListed = errors.New("not listed")
+
+// errNoName is the missing torrent name error.
+var errNoName = errors.New("no torrent name on details page")
+
+// IDError is a torrent id error.
+type IDError struct {
+	ID  int
+	Err error
+}
+
+// Error satisfies the error interface.
+func (err *IDError) Error() string {
+	return fmt.Sprintf("torrent %d: %v", err.ID, err.Err)
+}
+
+// Unwrap returns the underlying error.
+func (err *IDError) Unwrap() error {
+	return err.Err
+}
+
+// IDErrors are torrent id errors.
+type IDErrors []*IDError
+
+// Error satisfies the error interface.
+func (errs IDErrors) Error() string {
+	v := make([]string, len(errs))
+	for i, err := range errs {
+		v[i] = err.Error()
+	}
+	return fmt.Sprintf("%d torrent(s) failed: %s", len(errs), strings.Join(v, "; "))
+}
+
+// Torrents resolves the torrent ids to their browse metadata (see
+// TorrentInfo). Lookups are made concurrently, subject to any client budget.
+//
+// When one or more lookups fail, the resolved torrents are returned along
+// with an IDErrors error. Torrents that no longer exist fail with
+// ErrNotFound, and torrents missing from the search results fail with
+// ErrNotListed.
+func (cl *Client) Torrents(ctx context.Context, ids ...int) (map[int]Torrent, error) {
+	m := make(map[int]Torrent, len(ids))
+	var errs IDErrors
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ch := make(chan int)
+	for i := 0; i < bulkWorkers && i < len(ids); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range ch {
+				t, err := cl.lookup(ctx, id)
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, &IDError{ID: id, Err: err})
+				} else {
+					m[id] = *t
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	var ctxErr error
+loop:
+	for _, id := range ids {
+		select {
+		case <-ctx.Done():
+			ctxErr = ctx.Err()
+			break loop
+		case ch <- id:
+		}
+	}
+	close(ch)
+	wg.Wait()
+	switch {
+	case ctxErr != nil:
+		return m, ctxErr
+	case len(errs) != 0:
+		sort.Slice(errs, func(i, j int) bool {
+			return errs[i].ID < errs[j].ID
+		})
+		return m, errs
+	}
+	return m, nil
+}
+
+// TorrentInfo retrieves the browse metadata (name, size, seeders, category,
+// and so on) for the torrent id, by retrieving the torrent's details page
+// and then searching for its name. Returns ErrNotFound when the torrent no
+// longer exists (a 404 or 410 status), or ErrNotListed when the search does
+// not list it.
+func (cl *Client) TorrentInfo(ctx context.Context, id int) (*Torrent, error) {
+	t, err := cl.lookup(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("torrent %d: %w", id, err)
+	}
+	return t, nil
+}
+
+// lookup retrieves the browse metadata for the torrent id.
+func (cl *Client) lookup(ctx context.Context, id int) (*Torrent, error) {
+	d, err := cl.Details(ctx, id)
+	var serr *StatusError
+	switch {
+	case errors.As(err, &serr) && (serr.StatusCode == http.StatusNotFound || serr.StatusCode == http.StatusGone):
+		return nil, ErrNotFound
+	case err != nil:
+		return nil, err
+	}
+	if d.Name == "" {
+		return nil, errNoName
+	}
+	res, err := Search().WithExactPhrase(d.Name).Do(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range res.TorrentList {
+		if t.ID == id {
+			return &t, nil
+		}
+	}
+	return nil, ErrNotListed
+}
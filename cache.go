@@ -0,0 +1,270 @@
+package tlapi
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithCache is a TL client option to cache GET responses in the disk cache.
+// Cached responses are returned without sending a request (or waiting for
+// the client's limiter).
+func WithCache(c *DiskCache) Option {
+	return func(cl *Client) {
+		cl.cache = c
+	}
+}
+
+// maxCacheEntry is the maximum size of a cached response body.
+const maxCacheEntry = 16 << 20
+
+// DiskCache is a disk-backed http response cache, keyed by url, with a ttl
+// and a total size cap enforced by evicting the least recently used
+// entries. Html responses are not cached, as they may be error or challenge
+// pages. A disk cache is safe for concurrent use, but not for use by
+// multiple processes.
+type DiskCache struct {
+	dir     string
+	ttl     time.Duration
+	maxSize int64
+
+	mu   sync.Mutex
+	size int64
+}
+
+// cacheMeta is cache entry metadata.
+type cacheMeta struct {
+	URL         string    `json:"url"`
+	ContentType string    `json:"contentType,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// NewDiskCache creates a disk cache in the directory, with entries expiring
+// after ttl (never when 0), and a total size cap of maxSize bytes (unlimited
+// when 0).
+func NewDiskCache(dir string, ttl time.Duration, maxSize int64) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	c := &DiskCache{
+		dir:     dir,
+		ttl:     ttl,
+		maxSize: maxSize,
+	}
+	entries, err := c.entries()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		c.size += e.Size()
+	}
+	return c, nil
+}
+
+// path returns the entry path for the url.
+func (c *DiskCache) path(urlstr string) string {
+	sum := sha256.Sum256([]byte(urlstr))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+// Get returns the cached body and content type for the url.
+func (c *DiskCache) Get(urlstr string) ([]byte, string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p := c.path(urlstr)
+	buf, err := os.ReadFile(p)
+	if err != nil {
+		return nil, "", false
+	}
+	i := bytes.IndexByte(buf, '\n')
+	var meta cacheMeta
+	if i == -1 || json.Unmarshal(buf[:i], &meta) != nil || meta.URL != urlstr {
+		return nil, "", false
+	}
+	now := time.Now()
+	if c.ttl != 0 && now.Sub(meta.Time) >= c.ttl {
+		if os.Remove(p) == nil {
+			c.size -= int64(len(buf))
+		}
+		return nil, "", false
+	}
+	// access time is tracked with the modification time, for eviction
+	_ = os.Chtimes(p, now, now)
+	return buf[i+1:], meta.ContentType, true
+}
+
+// Put caches the body and content type for the url.
+func (c *DiskCache) Put(urlstr, contentType string, body []byte) error {
+	meta, err := json.Marshal(cacheMeta{
+		URL:         urlstr,
+		ContentType: contentType,
+		Time:        time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	buf := append(append(meta, '\n'), body...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p := c.path(urlstr)
+	if fi, err := os.Stat(p); err == nil {
+		c.size -= fi.Size()
+	}
+	f, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), p); err != nil {
+		return err
+	}
+	c.size += int64(len(buf))
+	return c.evict()
+}
+
+// Clear removes all entries from the cache.
+func (c *DiskCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries, err := c.entries()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := os.Remove(filepath.Join(c.dir, e.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	c.size = 0
+	return nil
+}
+
+// Size returns the total size of the cache entries.
+func (c *DiskCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// entries returns the cache entries.
+func (c *DiskCache) entries() ([]os.FileInfo, error) {
+	dirents, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil, err
+	}
+	var v []os.FileInfo
+	for _, d := range dirents {
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			continue
+		}
+		fi, err := d.Info()
+		if err != nil {
+			continue
+		}
+		v = append(v, fi)
+	}
+	return v, nil
+}
+
+// evict removes the least recently used entries until the cache is within
+// its size cap.
+func (c *DiskCache) evict() error {
+	if c.maxSize == 0 || c.size <= c.maxSize {
+		return nil
+	}
+	entries, err := c.entries()
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().Before(entries[j].ModTime())
+	})
+	for _, e := range entries {
+		if c.size <= c.maxSize {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.dir, e.Name())); err == nil {
+			c.size -= e.Size()
+		}
+	}
+	return nil
+}
+
+// cached returns the cached response for the request.
+func (c *DiskCache) cached(req *http.Request) (*http.Response, bool) {
+	if req.Method != "GET" {
+		return nil, false
+	}
+	body, contentType, ok := c.Get(req.URL.String())
+	if !ok {
+		return nil, false
+	}
+	header := make(http.Header)
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, true
+}
+
+// store caches the response for the request, returning a response with an
+// equivalent body. As the cache is only an optimization, failures to write
+// the entry are logged instead of failing the request.
+func (c *DiskCache) store(req *http.Request, res *http.Response, logf func(string, ...interface{})) (*http.Response, error) {
+	contentType := res.Header.Get("Content-Type")
+	if req.Method != "GET" || strings.Contains(contentType, "text/html") {
+		return res, nil
+	}
+	r := bufio.NewReader(res.Body)
+	if isHTML(contentType, r) {
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{r, res.Body}
+		return res, nil
+	}
+	buf, err := io.ReadAll(io.LimitReader(r, maxCacheEntry+1))
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	if len(buf) <= maxCacheEntry {
+		res.Body.Close()
+		res.Body = io.NopCloser(bytes.NewReader(buf))
+		if err := c.Put(req.URL.String(), contentType, buf); err != nil {
+			logf("cache: unable to store %s: %v", req.URL.Redacted(), err)
+		}
+		return res, nil
+	}
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), r), res.Body}
+	return res, nil
+}
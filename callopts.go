@@ -0,0 +1,138 @@
+package tlapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// CallOption is a per-call request option, overriding the client's defaults
+// for a single call.
+type CallOption func(*callOptions)
+
+// callOptions are per-call request options.
+type callOptions struct {
+	retries int
+	backoff time.Duration
+	timeout time.Duration
+	noCache bool
+}
+
+// WithCallRetries is a call option to retry the request up to n times when
+// it fails with a transient error (see IsTransient), with exponential
+// backoff starting at 1 second (see WithCallBackoff).
+func WithCallRetries(n int) CallOption {
+	return func(o *callOptions) {
+		o.retries = n
+	}
+}
+
+// WithCallBackoff is a call option to set the initial backoff between
+// retries (see WithCallRetries), doubled after each retry.
+func WithCallBackoff(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.backoff = d
+	}
+}
+
+// WithCallTimeout is a call option to set a timeout for each attempt of the
+// request, including reading the response body.
+func WithCallTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = d
+	}
+}
+
+// WithNoCache is a call option to bypass the client's cache (see
+// WithCache).
+func WithNoCache() CallOption {
+	return func(o *callOptions) {
+		o.noCache = true
+	}
+}
+
+// WithDefaultCallOptions is a TL client option to set default call options
+// for all requests made by the client. Call options passed at call time (or
+// with WithCallOptions) override the defaults.
+func WithDefaultCallOptions(opts ...CallOption) Option {
+	return func(cl *Client) {
+		cl.callOpts = append(cl.callOpts, opts...)
+	}
+}
+
+// callOptionsKey is the call options context key.
+type callOptionsKey struct{}
+
+// WithCallOptions returns a context that applies the call options to
+// requests made with it, for calls that do not accept call options
+// directly.
+func WithCallOptions(ctx context.Context, opts ...CallOption) context.Context {
+	prev, _ := ctx.Value(callOptionsKey{}).([]CallOption)
+	return context.WithValue(ctx, callOptionsKey{}, append(append([]CallOption(nil), prev...), opts...))
+}
+
+// callOptions returns the client's default call options, overridden by the
+// context's call options.
+func (cl *Client) callOptions(ctx context.Context) callOptions {
+	o := callOptions{
+		backoff: time.Second,
+	}
+	opts, _ := ctx.Value(callOptionsKey{}).([]CallOption)
+	for _, opt := range append(append([]CallOption(nil), cl.callOpts...), opts...) {
+		opt(&o)
+	}
+	return o
+}
+
+// sendCall sends the request, applying the context's call options.
+func (cl *Client) sendCall(ctx context.Context, req *http.Request) (*http.Response, error) {
+	o := cl.callOptions(ctx)
+	backoff := o.backoff
+	for retry := 0; ; retry++ {
+		res, err := cl.sendAttempt(ctx, req, o.timeout)
+		if err == nil || retry >= o.retries || ctx.Err() != nil || !IsTransient(err) {
+			return res, err
+		}
+		cl.logf("retrying %s %s in %v: %v", req.Method, req.URL.Redacted(), backoff, err)
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, newRequestError(req, ctx.Err())
+		case <-t.C:
+		}
+		backoff *= 2
+		if req, err = retryRequest(ctx, req); err != nil {
+			return nil, newRequestError(req, err)
+		}
+	}
+}
+
+// sendAttempt sends the request, applying the timeout.
+func (cl *Client) sendAttempt(ctx context.Context, req *http.Request, timeout time.Duration) (*http.Response, error) {
+	if timeout == 0 {
+		return cl.sendRefresh(ctx, req)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	res, err := cl.sendRefresh(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// cancelBody cancels a context when a response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close satisfies the io.Closer interface.
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
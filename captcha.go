@@ -0,0 +1,171 @@
+package tlapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+)
+
+// Captcha is a CAPTCHA challenge.
+type Captcha struct {
+	// Kind is the CAPTCHA kind (see Captcha constants).
+	Kind string
+	// SiteKey is the CAPTCHA site key.
+	SiteKey string
+	// URL is the url of the page containing the challenge.
+	URL string
+}
+
+// Captcha kinds.
+const (
+	CaptchaRecaptcha = "recaptcha"
+	CaptchaHcaptcha  = "hcaptcha"
+	CaptchaTurnstile = "turnstile"
+)
+
+// field returns the form field name for the challenge response.
+func (c *Captcha) field() string {
+	switch c.Kind {
+	case CaptchaHcaptcha:
+		return "h-captcha-response"
+	case CaptchaTurnstile:
+		return "cf-turnstile-response"
+	}
+	return "g-recaptcha-response"
+}
+
+// CaptchaSolver is the interface for CAPTCHA solvers, such as a manual prompt
+// or a solving service.
+type CaptchaSolver interface {
+	// SolveCaptcha solves the challenge, returning the response token.
+	SolveCaptcha(ctx context.Context, c *Captcha) (string, error)
+}
+
+// CaptchaSolverFunc wraps a func as a CaptchaSolver.
+type CaptchaSolverFunc func(ctx context.Context, c *Captcha) (string, error)
+
+// SolveCaptcha satisfies the CaptchaSolver interface.
+func (f CaptchaSolverFunc) SolveCaptcha(ctx context.Context, c *Captcha) (string, error) {
+	return f(ctx, c)
+}
+
+// WithCaptchaSolver is a TL client option to set the CAPTCHA solver, invoked
+// when login or download responses contain a CAPTCHA challenge.
+func WithCaptchaSolver(solver CaptchaSolver) Option {
+	return func(cl *Client) {
+		cl.captcha = solver
+	}
+}
+
+// ErrCaptcha is the CAPTCHA error.
+var ErrCaptcha = errors.New("captcha challenge")
+
+// CaptchaError is a CAPTCHA challenge error, returned when a response
+// contains a CAPTCHA challenge and no solver is configured.
+type CaptchaError struct {
+	Captcha *Captcha
+}
+
+// Error satisfies the error interface.
+func (err *CaptchaError) Error() string {
+	return fmt.Sprintf("%s challenge at %s", err.Captcha.Kind, err.Captcha.URL)
+}
+
+// Unwrap returns ErrCaptcha.
+func (err *CaptchaError) Unwrap() error {
+	return ErrCaptcha
+}
+
+// captchaRE matches CAPTCHA widgets and their site keys.
+var captchaRE = regexp.MustCompile(`(?i)class=["'][^"']*\b(g-recaptcha|h-captcha|cf-turnstile)\b[^"']*["'][^>]*\bdata-sitekey=["']([^"']+)["']|\bdata-sitekey=["']([^"']+)["'][^>]*class=["'][^"']*\b(g-recaptcha|h-captcha|cf-turnstile)\b`)
+
+// detectCaptcha detects a CAPTCHA challenge in the html page.
+func detectCaptcha(u *url.URL, buf []byte) *Captcha {
+	m := captchaRE.FindSubmatch(buf)
+	if m == nil {
+		return nil
+	}
+	class, key := m[1], m[2]
+	if class == nil {
+		class, key = m[4], m[3]
+	}
+	c := &Captcha{
+		SiteKey: string(key),
+		URL:     u.String(),
+	}
+	switch string(bytes.ToLower(class)) {
+	case "h-captcha":
+		c.Kind = CaptchaHcaptcha
+	case "cf-turnstile":
+		c.Kind = CaptchaTurnstile
+	default:
+		c.Kind = CaptchaRecaptcha
+	}
+	return c
+}
+
+// solveCaptcha solves the challenge using the client's solver, posting the
+// response to the challenge url.
+func (cl *Client) solveCaptcha(ctx context.Context, c *Captcha) error {
+	if cl.captcha == nil {
+		return &CaptchaError{Captcha: c}
+	}
+	token, err := cl.captcha.SolveCaptcha(ctx, c)
+	if err != nil {
+		return fmt.Errorf("unable to solve %s: %w", c.Kind, err)
+	}
+	res, err := cl.PostForm(ctx, c.URL, url.Values{c.field(): {token}})
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// download retrieves the file at the path, solving a CAPTCHA challenge (at
+// most once) when the response is a html page containing one.
+func (cl *Client) download(ctx context.Context, path string) ([]byte, error) {
+	for i := 0; ; i++ {
+		res, err := cl.Get(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		buf, c, err := readDownload(res)
+		switch {
+		case err != nil:
+			return nil, newRequestError(res.Request, err)
+		case c == nil:
+			return buf, nil
+		case i != 0:
+			return nil, newRequestError(res.Request, &CaptchaError{Captcha: c})
+		}
+		if err := cl.solveCaptcha(ctx, c); err != nil {
+			return nil, newRequestError(res.Request, err)
+		}
+	}
+}
+
+// readDownload reads the download response, returning the CAPTCHA challenge
+// when the response is a html page containing one, or a html error when the
+// response is any other html page.
+func readDownload(res *http.Response) ([]byte, *Captcha, error) {
+	defer res.Body.Close()
+	r := bufio.NewReader(res.Body)
+	if !isHTML(res.Header.Get("Content-Type"), r) {
+		buf, err := io.ReadAll(r)
+		return buf, nil, err
+	}
+	buf, err := io.ReadAll(io.LimitReader(r, 1<<20))
+	if err != nil {
+		return nil, nil, err
+	}
+	if c := detectCaptcha(res.Request.URL, buf); c != nil {
+		return nil, c, nil
+	}
+	return nil, nil, newHTMLError(res.Request.URL, bytes.NewReader(buf))
+}
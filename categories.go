@@ -0,0 +1,166 @@
+package tlapi
+
+//go:generate go run gen.go
+
+// Kind is a category media kind.
+type Kind string
+
+// Kind values.
+const (
+	KindVideo Kind = "video"
+	KindAudio Kind = "audio"
+	KindGame  Kind = "game"
+	KindApp   Kind = "app"
+	KindBook  Kind = "book"
+)
+
+// Category groups.
+const (
+	GroupMovies    = "Movies"
+	GroupTV        = "TV"
+	GroupGames     = "Games"
+	GroupApps      = "Apps"
+	GroupEducation = "Education"
+	GroupAnimation = "Animation"
+	GroupBooks     = "Books"
+	GroupMusic     = "Music"
+	GroupForeign   = "Foreign"
+)
+
+// CategoryInfo is category metadata.
+type CategoryInfo struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Group string `json:"group"`
+	Kind  Kind   `json:"kind"`
+}
+
+// String satisfies the fmt.Stringer interface.
+func (c CategoryInfo) String() string {
+	if c.Group == "" || c.Group == c.Name {
+		return c.Name
+	}
+	return c.Group + " :: " + c.Name
+}
+
+// categories are the known categories, in site display order, followed by
+// any generated categories.
+var categories = append([]CategoryInfo{
+	{CategoryMoviesCam, "Cam", GroupMovies, KindVideo},
+	{CategoryMoviesTSTC, "TS/TC", GroupMovies, KindVideo},
+	{CategoryMoviesDVDRipDVDScreener, "DVDRip/DVDScreener", GroupMovies, KindVideo},
+	{CategoryMoviesWebRip, "WEBRip", GroupMovies, KindVideo},
+	{CategoryMoviesHDRip, "HDRip", GroupMovies, KindVideo},
+	{CategoryMoviesBluRayRip, "BlurayRip", GroupMovies, KindVideo},
+	{CategoryMoviesDVDR, "DVD-R", GroupMovies, KindVideo},
+	{CategoryMoviesBluRay, "Bluray", GroupMovies, KindVideo},
+	{CategoryMovies4k, "4K", GroupMovies, KindVideo},
+	{CategoryMoviesBoxsets, "Boxsets", GroupMovies, KindVideo},
+	{CategoryMoviesDocumentaries, "Documentaries", GroupMovies, KindVideo},
+
+	{CategoryTVEpisodes, "Episodes", GroupTV, KindVideo},
+	{CategoryTVEpisodesHD, "Episodes HD", GroupTV, KindVideo},
+	{CategoryTVBoxsets, "Boxsets", GroupTV, KindVideo},
+
+	{CategoryGamesPC, "PC", GroupGames, KindGame},
+	{CategoryGamesMac, "Mac", GroupGames, KindGame},
+	{CategoryGamesXbox, "XBOX", GroupGames, KindGame},
+	{CategoryGamesXbox360, "XBOX360", GroupGames, KindGame},
+	{CategoryGamesXboxOne, "XBOXONE", GroupGames, KindGame},
+	{CategoryGamesPS2, "PS2", GroupGames, KindGame},
+	{CategoryGamesPS3, "PS3", GroupGames, KindGame},
+	{CategoryGamesPS4, "PS4", GroupGames, KindGame},
+	{CategoryGamesPS5, "PS5", GroupGames, KindGame},
+	{CategoryGamesPSP, "PSP", GroupGames, KindGame},
+	{CategoryGamesWii, "Wii", GroupGames, KindGame},
+	{CategoryGamesNintendoDS, "Nintendo DS", GroupGames, KindGame},
+	{CategoryGamesNintendoSwitch, "Nintendo Switch", GroupGames, KindGame},
+
+	{CategoryAppsPCISO, "PC-ISO", GroupApps, KindApp},
+	{CategoryAppsMac, "Mac", GroupApps, KindApp},
+	{CategoryAppsMobile, "Mobile", GroupApps, KindApp},
+	{CategoryApps0Day, "0-day", GroupApps, KindApp},
+
+	{CategoryEducation, "Education", GroupEducation, KindVideo},
+
+	{CategoryAnimationAnime, "Anime", GroupAnimation, KindVideo},
+	{CategoryAnimationCartoons, "Cartoons", GroupAnimation, KindVideo},
+
+	{CategoryBooksEbooks, "EBooks", GroupBooks, KindBook},
+	{CategoryBooksComics, "Comics", GroupBooks, KindBook},
+
+	{CategoryMusicAudio, "Audio", GroupMusic, KindAudio},
+	{CategoryMusicVideos, "Music Videos", GroupMusic, KindVideo},
+
+	{CategoryForeignMovies, "Movies", GroupForeign, KindVideo},
+	{CategoryForeignTVSeries, "TV Series", GroupForeign, KindVideo},
+}, genCategories...)
+
+// categoryIndex is the category id index.
+var categoryIndex = func() map[int]int {
+	m := make(map[int]int, len(categories))
+	for i, c := range categories {
+		m[c.ID] = i
+	}
+	return m
+}()
+
+// Categories returns metadata for all known categories, in site display
+// order.
+func Categories() []CategoryInfo {
+	return append([]CategoryInfo(nil), categories...)
+}
+
+// CategoryByID returns the category metadata for the id.
+func CategoryByID(id int) (CategoryInfo, bool) {
+	i, ok := categoryIndex[id]
+	if !ok {
+		return CategoryInfo{}, false
+	}
+	return categories[i], true
+}
+
+// CategoryName returns the display name ("Group :: Name") for the category
+// id, or an empty string when the category is unknown.
+func CategoryName(id int) string {
+	if c, ok := CategoryByID(id); ok {
+		return c.String()
+	}
+	return ""
+}
+
+// CategoriesByGroup returns the categories in the group.
+func CategoriesByGroup(group string) []CategoryInfo {
+	var v []CategoryInfo
+	for _, c := range categories {
+		if c.Group == group {
+			v = append(v, c)
+		}
+	}
+	return v
+}
+
+// CategoriesByKind returns the categories of the media kind.
+func CategoriesByKind(kind Kind) []CategoryInfo {
+	var v []CategoryInfo
+	for _, c := range categories {
+		if c.Kind == kind {
+			v = append(v, c)
+		}
+	}
+	return v
+}
+
+// CategoryIDs returns the ids for the categories.
+func CategoryIDs(cats []CategoryInfo) []int {
+	v := make([]int, len(cats))
+	for i, c := range cats {
+		v[i] = c.ID
+	}
+	return v
+}
+
+// Category returns the category metadata for the torrent.
+func (t Torrent) Category() (CategoryInfo, bool) {
+	return CategoryByID(t.CategoryID)
+}
@@ -0,0 +1,38 @@
+package tlapi
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+)
+
+// ErrCloudflareChallenge is the Cloudflare challenge error, matched (with
+// errors.Is) by the StatusError or HTMLError returned when the site responds
+// with a Cloudflare challenge, usually because the cf_clearance cookie is
+// missing or no longer valid. See OnCookieExpired and WithFlareSolverr for
+// refreshing the clearance cookie.
+var ErrCloudflareChallenge = errors.New("cloudflare challenge")
+
+// challengeRE matches the markers of a Cloudflare challenge page.
+var challengeRE = regexp.MustCompile(`(?i)/cdn-cgi/challenge-platform/|cf_chl_opt|cf-chl-|<title>just a moment\.\.\.</title>|<title>attention required! \| cloudflare</title>`)
+
+// isChallenge determines if the response is a Cloudflare challenge, from the
+// Cf-Mitigated header, or the challenge page markers in the start of a
+// forbidden (403) or unavailable (503) response's body. The body is restored
+// after reading.
+func isChallenge(res *http.Response) bool {
+	if res.Header.Get("Cf-Mitigated") == "challenge" {
+		return true
+	}
+	if res.StatusCode != http.StatusForbidden && res.StatusCode != http.StatusServiceUnavailable {
+		return false
+	}
+	buf, _ := io.ReadAll(io.LimitReader(res.Body, 65536))
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), res.Body), res.Body}
+	return challengeRE.Match(buf)
+}
@@ -1,17 +1,25 @@
 package tlapi
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
-	"net/http/cookiejar"
 	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
-
-	"golang.org/x/net/publicsuffix"
 )
 
 // Client is a TL client.
@@ -19,65 +27,471 @@ type Client struct {
 	cl        *http.Client
 	Jar       http.CookieJar
 	Transport http.RoundTripper
+	base      string
+	strict    bool
+	userAgent string
+	requestID func() string
+	doh       string
+	otp       func(context.Context) (string, error)
+	captcha   CaptchaSolver
+	limiter   Limiter
+	index     *HashIndex
+	maxWaits  int
+	logf      func(string, ...interface{})
+
+	refresh     func(context.Context) ([]*http.Cookie, error)
+	refreshMu   sync.RWMutex
+	gen         uint64
+	clearance   time.Time
+	solverAgent string
+
+	pauseAfter int
+	onPause    func(error)
+	pauseMu    sync.Mutex
+	blocks     int
+	paused     bool
+
+	stats     clientStats
+	cache     *DiskCache
+	onUnknown func(endpoint, field string, value json.RawMessage)
+	callOpts  []CallOption
+	nextDelay time.Duration
+
+	maxBodySize int64
+	sanitizer   *Sanitizer
+	passkey     string
+	announce    string
+	passkeyMu   sync.Mutex
+
+	htmlFallback        bool
+	skipInvalid         bool
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+	timeout             time.Duration
 }
 
 // New creates a TL client.
 func New(opts ...Option) *Client {
-	cl := &Client{}
+	cl := &Client{
+		base:                baseURL,
+		maxWaits:            5,
+		logf:                func(string, ...interface{}) {},
+		maxIdleConnsPerHost: 8,
+		idleConnTimeout:     90 * time.Second,
+		sanitizer:           NewSanitizer(),
+	}
 	for _, o := range opts {
 		o(cl)
 	}
+	if cl.sanitizer != nil {
+		cl.logf = cl.sanitizer.Logf(cl.logf)
+	}
+	if cl.Transport == nil {
+		cl.Transport = cl.buildTransport()
+	}
 	if cl.cl == nil {
 		cl.cl = &http.Client{
 			Jar:       cl.Jar,
 			Transport: cl.Transport,
+			Timeout:   cl.timeout,
 		}
 	}
 	return cl
 }
 
-// Do executes a request.
-func (cl *Client) Do(ctx context.Context, req *http.Request, result interface{}) error {
-	if cl.Jar == nil {
-		return errors.New("must supply cookie jar")
+// buildTransport builds the default http transport, used when no transport
+// was supplied. Keep-alives, http/2, TLS session resumption, and gzip
+// compression are enabled.
+func (cl *Client) buildTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
 	}
-	req.Header.Set("Content-Type", "application/json")
-	res, err := cl.cl.Do(req.WithContext(ctx))
+	dial := dialer.DialContext
+	if cl.doh != "" {
+		dial = newDoHResolver(cl.doh, dialer).DialContext
+	}
+	return &http.Transport{
+		Proxy:       http.ProxyFromEnvironment,
+		DialContext: dial,
+		TLSClientConfig: &tls.Config{
+			ClientSessionCache: tls.NewLRUClientSessionCache(0),
+		},
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   cl.maxIdleConnsPerHost,
+		IdleConnTimeout:       cl.idleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// Do executes a request, applying the call options.
+func (cl *Client) Do(ctx context.Context, req *http.Request, result interface{}, opts ...CallOption) error {
+	if len(opts) != 0 {
+		ctx = WithCallOptions(ctx, opts...)
+	}
+	res, err := cl.do(ctx, req)
 	if err != nil {
 		return err
 	}
 	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("invalid http status %d", res.StatusCode)
+	if err := cl.decode(res, result); err != nil {
+		return newRequestError(req, err)
+	}
+	return nil
+}
+
+// decode decodes the json response body into result.
+func (cl *Client) decode(res *http.Response, result interface{}) error {
+	r := bufio.NewReader(res.Body)
+	if isHTML(res.Header.Get("Content-Type"), r) {
+		return newHTMLError(res.Request.URL, r)
+	}
+	if !cl.strict && cl.onUnknown == nil {
+		return cl.decodeResult(json.NewDecoder(r), result)
+	}
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if cl.onUnknown != nil {
+		endpoint, seen := res.Request.URL.Path, make(map[string]bool)
+		unknownFields(buf, reflect.TypeOf(result), "", func(field string, value json.RawMessage) {
+			if !seen[field] {
+				seen[field] = true
+				cl.onUnknown(endpoint, field, value)
+			}
+		})
+	}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	if cl.strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := cl.decodeResult(dec, result); err != nil {
+		return err
+	}
+	if _, ok := result.(*SearchResponse); ok && cl.strict {
+		return checkTorrentFields(buf)
+	}
+	return nil
+}
+
+// decodeResult decodes the result, skipping invalid torrents in search
+// responses when enabled.
+func (cl *Client) decodeResult(dec *json.Decoder, result interface{}) error {
+	if res, ok := result.(*SearchResponse); ok && cl.skipInvalid {
+		return decodeSkipInvalid(dec, res)
 	}
-	dec := json.NewDecoder(res.Body)
-	dec.DisallowUnknownFields()
 	return dec.Decode(result)
 }
 
-// Search searches for a query.
-func (cl *Client) Search(ctx context.Context, query ...string) (*SearchResponse, error) {
-	return Search(query...).Do(ctx, cl)
+// GetJSON retrieves the path (relative to the site's base url) and decodes
+// the json response into out. Useful for site endpoints not otherwise modeled
+// by the package.
+func (cl *Client) GetJSON(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequest("GET", cl.url(path), nil)
+	if err != nil {
+		return err
+	}
+	return cl.Do(ctx, req, out)
 }
 
-// Torrent retrieves a torrent for the id.
-func (cl *Client) Torrent(ctx context.Context, id int) ([]byte, error) {
+// Do executes the request against the client, decoding the json response
+// into a new T.
+func Do[T any](ctx context.Context, cl *Client, req *http.Request, opts ...CallOption) (*T, error) {
+	v := new(T)
+	if err := cl.Do(ctx, req, v, opts...); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// url returns the absolute url for the path.
+func (cl *Client) url(path string) string {
+	if strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://") {
+		return path
+	}
+	return cl.base + "/" + strings.TrimPrefix(path, "/")
+}
+
+// Get retrieves the path (relative to the site's base url), returning the
+// response when the http status is OK. The caller must close the response
+// body.
+func (cl *Client) Get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", cl.url(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	return cl.send(ctx, req)
+}
+
+// PostForm posts the form values to the path (relative to the site's base
+// url), returning the response when the http status is OK. The caller must
+// close the response body.
+func (cl *Client) PostForm(ctx context.Context, path string, values url.Values) (*http.Response, error) {
+	req, err := http.NewRequest("POST", cl.url(path), strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return cl.send(ctx, req)
+}
+
+// do executes a json request, returning the response when the http status is
+// OK. The caller must close the response body.
+func (cl *Client) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	req.Header.Set("Content-Type", "application/json")
+	return cl.send(ctx, req)
+}
+
+// send sends the request with the client's cookies and user agent, returning
+// the response when the http status is OK. The caller must close the response
+// body.
+func (cl *Client) send(ctx context.Context, req *http.Request) (*http.Response, error) {
 	if cl.Jar == nil {
 		return nil, errors.New("must supply cookie jar")
 	}
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://www.torrentleech.org/download/%d/%s", id, "a"), nil)
+	if agent := cl.agent(); agent != "" {
+		req.Header.Set("User-Agent", agent)
+	}
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		req.Header.Set("X-Request-ID", id)
+	} else if cl.requestID != nil {
+		req.Header.Set("X-Request-ID", cl.requestID())
+	}
+	return cl.sendCall(ctx, req)
+}
+
+// sendRefresh sends the request, refreshing the client's cookies and
+// retrying once when the site responds with a forbidden (403) status or a
+// Cloudflare challenge and a cookie refresh func is set. The cookies of a
+// paused client are refreshed before sending.
+func (cl *Client) sendRefresh(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if cl.refresh == nil {
+		return cl.execWait(ctx, req)
+	}
+	gen, err := cl.refreshGen(ctx)
 	if err != nil {
-		return nil, err
+		return nil, newRequestError(req, err)
+	}
+	if cl.Paused() {
+		// a successful refresh resumes the client (see SetCookies)
+		if err := cl.refreshCookies(ctx, gen); err != nil {
+			return nil, newRequestError(req, fmt.Errorf("%w: cookie refresh: %v", ErrPaused, err))
+		}
+		if agent := cl.agent(); agent != "" {
+			req.Header.Set("User-Agent", agent)
+		}
+	}
+	res, err := cl.execWait(ctx, req)
+	var statusErr *StatusError
+	if err == nil || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden && !statusErr.Challenge {
+		return res, err
+	}
+	if err := cl.refreshCookies(ctx, gen); err != nil {
+		return nil, newRequestError(req, err)
+	}
+	if req, err = retryRequest(ctx, req); err != nil {
+		return nil, newRequestError(req, err)
+	}
+	if agent := cl.agent(); agent != "" {
+		// the refresh may have changed the user agent (see WithFlareSolverr)
+		req.Header.Set("User-Agent", agent)
 	}
+	return cl.execWait(ctx, req)
+}
+
+// execWait executes the request, waiting and retrying when the site responds
+// with a rate limit (429) or wait page.
+func (cl *Client) execWait(ctx context.Context, req *http.Request) (*http.Response, error) {
+	for i := 0; ; i++ {
+		res, err := cl.exec(ctx, req)
+		var statusErr *StatusError
+		if err == nil || i >= cl.maxWaits || !errors.As(err, &statusErr) || statusErr.RetryAfter <= 0 {
+			return res, err
+		}
+		cl.logf("rate limited (http status %d), waiting %v before retrying %s %s", statusErr.StatusCode, statusErr.RetryAfter, req.Method, req.URL.Redacted())
+		t := time.NewTimer(statusErr.RetryAfter)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, newRequestError(req, ctx.Err())
+		case <-t.C:
+		}
+		if req, err = retryRequest(ctx, req); err != nil {
+			return nil, newRequestError(req, err)
+		}
+	}
+}
+
+// exec executes the request, returning the response when the http status is
+// OK.
+func (cl *Client) exec(ctx context.Context, req *http.Request) (*http.Response, error) {
+	noCache := cl.callOptions(ctx).noCache
+	if cl.cache != nil && !noCache {
+		if res, ok := cl.cache.cached(req); ok {
+			return res, nil
+		}
+	}
+	if err := cl.checkPaused(); err != nil {
+		return nil, newRequestError(req, err)
+	}
+	if cl.limiter != nil {
+		if err := cl.limiter.Wait(ctx); err != nil {
+			return nil, newRequestError(req, err)
+		}
+	}
+	start := time.Now()
 	res, err := cl.cl.Do(req.WithContext(ctx))
+	cl.stats.track(time.Since(start), req, res, err)
 	if err != nil {
-		return nil, err
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, newRequestError(req, err)
 	}
-	defer res.Body.Close()
+	cl.trackBlocked(res)
 	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("invalid http status %d", res.StatusCode)
+		defer res.Body.Close()
+		statusErr := &StatusError{
+			StatusCode: res.StatusCode,
+			Challenge:  isChallenge(res),
+		}
+		if !statusErr.Challenge {
+			// waiting does not pass a challenge
+			statusErr.RetryAfter = retryAfter(res)
+		}
+		return nil, newRequestError(req, statusErr)
+	}
+	if isLogin(res.Request.URL) && !isLogin(req.URL) {
+		defer res.Body.Close()
+		return nil, newRequestError(req, ErrSessionExpired)
+	}
+	if cl.refresh != nil {
+		cl.trackClearance(res.Cookies())
+	}
+	if err := cl.limitBody(res); err != nil {
+		return nil, newRequestError(req, err)
+	}
+	if cl.cache != nil && !noCache {
+		if res, err = cl.cache.store(req, res, cl.logf); err != nil {
+			return nil, newRequestError(req, err)
+		}
 	}
-	return io.ReadAll(res.Body)
+	return res, nil
+}
+
+// retryRequest returns a copy of the request for retrying, with a fresh body.
+func retryRequest(ctx context.Context, req *http.Request) (*http.Request, error) {
+	r := req.Clone(ctx)
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, errors.New("cannot retry request body")
+		}
+		var err error
+		if r.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// isLogin determines if the url is the site's login page.
+func isLogin(u *url.URL) bool {
+	return strings.HasPrefix(u.Path, "/user/account/login")
+}
+
+// Ping performs a cheap authenticated request, returning ErrSessionExpired
+// when the session is no longer valid.
+func (cl *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequest("HEAD", cl.url("/"), nil)
+	if err != nil {
+		return err
+	}
+	res, err := cl.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// Logout invalidates the session on the site, and clears the session cookies
+// from the cookie jar.
+func (cl *Client) Logout(ctx context.Context) error {
+	res, err := cl.Get(ctx, "/user/account/logout")
+	switch {
+	case err == nil:
+		res.Body.Close()
+	case !errors.Is(err, ErrSessionExpired):
+		return err
+	}
+	cl.clearCookies()
+	return nil
+}
+
+// clearCookies expires all cookies in the jar for the site.
+func (cl *Client) clearCookies() {
+	u, err := url.Parse(cl.url("/"))
+	if err != nil {
+		return
+	}
+	domain := cookieDomain(u)
+	var cookies []*http.Cookie
+	for _, c := range cl.Jar.Cookies(u) {
+		cookies = append(cookies, &http.Cookie{
+			Name:   c.Name,
+			Path:   "/",
+			MaxAge: -1,
+		}, &http.Cookie{
+			Name:   c.Name,
+			Domain: domain,
+			Path:   "/",
+			MaxAge: -1,
+		})
+	}
+	cl.Jar.SetCookies(u, cookies)
+}
+
+// StartKeepalive starts a background task that pings the site every
+// interval, keeping the session and Cloudflare clearance warm. Failures are
+// passed to onFail, when not nil. The task stops when the context is closed.
+func (cl *Client) StartKeepalive(ctx context.Context, interval time.Duration, onFail func(error)) {
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+			if err := cl.Ping(ctx); err != nil && onFail != nil && ctx.Err() == nil {
+				onFail(err)
+			}
+		}
+	}()
+}
+
+// Search searches for a query.
+func (cl *Client) Search(ctx context.Context, query ...string) (*SearchResponse, error) {
+	return Search(query...).Do(ctx, cl)
+}
+
+// Torrent retrieves a torrent for the id.
+func (cl *Client) Torrent(ctx context.Context, id int) ([]byte, error) {
+	buf, err := cl.download(ctx, fmt.Sprintf("/download/%d/%s", id, "a"))
+	if err != nil {
+		return nil, fmt.Errorf("torrent %d: %w", id, err)
+	}
+	if cl.index != nil {
+		if _, err := cl.index.AddMetainfo(id, buf); err != nil {
+			// indexing is a side effect, and does not fail the download
+			cl.logf("torrent %d: unable to index: %v", id, err)
+		}
+	}
+	return buf, nil
 }
 
 // Option is a TL client option.
@@ -98,33 +512,134 @@ func WithTransport(transport http.RoundTripper) Option {
 	}
 }
 
+// WithLogf is a TL client option to set a log func, used to surface
+// conditions such as rate limit waits.
+func WithLogf(logf func(string, ...interface{})) Option {
+	return func(cl *Client) {
+		if logf == nil {
+			logf = func(string, ...interface{}) {}
+		}
+		cl.logf = logf
+	}
+}
+
+// WithMaxWaits is a TL client option to set the maximum number of times a
+// request is retried after waiting when the site responds with a rate limit
+// (429) or wait page. Defaults to 5.
+func WithMaxWaits(n int) Option {
+	return func(cl *Client) {
+		cl.maxWaits = n
+	}
+}
+
+// WithUserAgent is a TL client option to set the User-Agent header sent with
+// each request. Should match the browser the cookies were retrieved from.
+func WithUserAgent(userAgent string) Option {
+	return func(cl *Client) {
+		cl.userAgent = userAgent
+	}
+}
+
+// WithRequestID is a TL client option to stamp each request with a
+// X-Request-ID header, generated by f, for correlating requests across logs.
+// When f is nil, random ids are generated. An id set on the context with
+// WithContextRequestID takes precedence.
+func WithRequestID(f func() string) Option {
+	return func(cl *Client) {
+		if f == nil {
+			f = NewRequestID
+		}
+		cl.requestID = f
+	}
+}
+
+// requestIDKey is the request id context key.
+type requestIDKey struct{}
+
+// WithContextRequestID returns a context that stamps requests made with it
+// with the X-Request-ID header id.
+func WithContextRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// NewRequestID generates a random request id.
+func NewRequestID() string {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf[:])
+}
+
+// WithMaxIdleConnsPerHost is a TL client option to set the maximum idle
+// (keep-alive) connections per host for the default http transport.
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(cl *Client) {
+		cl.maxIdleConnsPerHost = n
+	}
+}
+
+// WithIdleConnTimeout is a TL client option to set the idle connection
+// timeout for the default http transport.
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(cl *Client) {
+		cl.idleConnTimeout = d
+	}
+}
+
+// WithTimeout is a TL client option to set the overall timeout for each http
+// request, including reading the response body. Zero means no timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(cl *Client) {
+		cl.timeout = d
+	}
+}
+
+// WithStrictDecoding is a TL client option to set strict response decoding.
+// When enabled, responses containing unknown fields are treated as errors.
+// Responses are decoded leniently by default.
+func WithStrictDecoding(strict bool) Option {
+	return func(cl *Client) {
+		cl.strict = strict
+	}
+}
+
 // WithCreds is a TL client option to set the PHPSESSID, tluid, and tlpass
 // cookies used by the TL client.
 func WithCreds(sessID, uid, pass string) Option {
 	return func(cl *Client) {
 		var err error
-		if cl.Jar, err = BuildJar(sessID, uid, pass); err != nil {
+		if cl.Jar, err = NewJar(cl.base, credCookies(sessID, uid, pass)...); err != nil {
 			panic(err)
 		}
 	}
 }
 
+// WithBaseURL is a TL client option to set the site's base url (for example,
+// a mirror). Cookies already in the client's jar for the previous base url
+// are copied to the new base url.
+func WithBaseURL(baseURL string) Option {
+	return func(cl *Client) {
+		baseURL = strings.TrimSuffix(baseURL, "/")
+		if cl.Jar != nil && cl.base != baseURL {
+			if err := copyCookies(cl.Jar, cl.base, baseURL); err != nil {
+				panic(err)
+			}
+		}
+		cl.base = baseURL
+	}
+}
+
 // BuildJar creates a jar.
 func BuildJar(sessID, uid, pass string) (http.CookieJar, error) {
-	jar, err := cookiejar.New(&cookiejar.Options{
-		PublicSuffixList: publicsuffix.List,
-	})
-	if err != nil {
-		return nil, err
-	}
-	u, err := url.Parse("https://torrentleech.org/")
-	if err != nil {
-		return nil, err
-	}
+	return NewJar(baseURL, credCookies(sessID, uid, pass)...)
+}
+
+// credCookies returns the PHPSESSID, tluid, and tlpass cookies.
+func credCookies(sessID, uid, pass string) []*http.Cookie {
 	expires := time.Now().Add(10 * 365 * 24 * time.Hour)
-	jar.SetCookies(u, []*http.Cookie{
+	return []*http.Cookie{
 		{
-			Domain:  "www.torrentleech.org",
 			Path:    "/",
 			Name:    "PHPSESSID",
 			Value:   sessID,
@@ -132,7 +647,6 @@ func BuildJar(sessID, uid, pass string) (http.CookieJar, error) {
 			Secure:  true,
 		},
 		{
-			Domain:   "torrentleech.org",
 			Path:     "/",
 			Name:     "tluid",
 			Value:    uid,
@@ -141,13 +655,168 @@ func BuildJar(sessID, uid, pass string) (http.CookieJar, error) {
 			Secure:   true,
 		},
 		{
-			Domain:  "torrentleech.org",
 			Path:    "/",
 			Name:    "tlpass",
 			Value:   pass,
 			Expires: expires,
 			Secure:  true,
 		},
-	})
-	return jar, nil
+	}
 }
+
+// ErrSessionExpired is the session expired error, returned when a request is
+// redirected to the site's login page.
+var ErrSessionExpired = errors.New("session expired")
+
+// RequestError is a request error, wrapping the underlying error with the
+// request method and url.
+type RequestError struct {
+	Method    string
+	URL       string
+	RequestID string
+	Err       error
+}
+
+// newRequestError creates a request error for the request.
+func newRequestError(req *http.Request, err error) error {
+	return &RequestError{
+		Method:    req.Method,
+		URL:       req.URL.Redacted(),
+		RequestID: req.Header.Get("X-Request-ID"),
+		Err:       err,
+	}
+}
+
+// Error satisfies the error interface.
+func (err *RequestError) Error() string {
+	if err.RequestID != "" {
+		return err.Method + " " + err.URL + " (request " + err.RequestID + "): " + err.Err.Error()
+	}
+	return err.Method + " " + err.URL + ": " + err.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (err *RequestError) Unwrap() error {
+	return err.Err
+}
+
+// StatusError is an invalid http status error.
+type StatusError struct {
+	StatusCode int
+	// RetryAfter is the wait requested by the site before retrying, parsed
+	// from the Retry-After header or the site's wait page.
+	RetryAfter time.Duration
+	// Challenge is true when the response is a Cloudflare challenge.
+	Challenge bool
+}
+
+// Error satisfies the error interface.
+func (err *StatusError) Error() string {
+	if err.Challenge {
+		return fmt.Sprintf("invalid http status %d (%v)", err.StatusCode, ErrCloudflareChallenge)
+	}
+	return fmt.Sprintf("invalid http status %d", err.StatusCode)
+}
+
+// Is returns true for ErrCloudflareChallenge when the response is a
+// Cloudflare challenge.
+func (err *StatusError) Is(target error) bool {
+	return target == ErrCloudflareChallenge && err.Challenge
+}
+
+// retryAfter returns the wait requested by a rate limited (429) or
+// unavailable (503) response, from the Retry-After header or the wait time
+// embedded in the response's page. Rate limited responses without a wait
+// return a default wait.
+func retryAfter(res *http.Response) time.Duration {
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+	var d time.Duration
+	if s := strings.TrimSpace(res.Header.Get("Retry-After")); s != "" {
+		if i, err := strconv.Atoi(s); err == nil {
+			d = time.Duration(i) * time.Second
+		} else if t, err := http.ParseTime(s); err == nil {
+			d = time.Until(t)
+		}
+	} else {
+		buf, _ := io.ReadAll(io.LimitReader(res.Body, 65536))
+		if m := waitRE.FindSubmatch(buf); m != nil {
+			i, _ := strconv.Atoi(string(m[1]))
+			d = time.Duration(i) * time.Second
+			if bytes.HasPrefix(bytes.ToLower(m[2]), []byte("min")) {
+				d *= 60
+			}
+		}
+	}
+	switch {
+	case d <= 0 && res.StatusCode == http.StatusTooManyRequests:
+		return 30 * time.Second
+	case d > 15*time.Minute:
+		return 15 * time.Minute
+	}
+	return d
+}
+
+// waitRE matches the wait time embedded in a wait page.
+var waitRE = regexp.MustCompile(`(?i)(?:wait|try again in|slow down)\D{0,32}?(\d+)\s*(seconds?|secs?|minutes?|mins?)\b`)
+
+// ErrUnexpectedHTML is the unexpected html response error.
+var ErrUnexpectedHTML = errors.New("unexpected html response")
+
+// HTMLError is an unexpected html response error, returned when a html page
+// (such as a login or maintenance page) is received in place of a json
+// response.
+type HTMLError struct {
+	URL     string
+	Snippet string
+	// Challenge is true when the page is a Cloudflare challenge.
+	Challenge bool
+}
+
+// Error satisfies the error interface.
+func (err *HTMLError) Error() string {
+	if err.Challenge {
+		return fmt.Sprintf("unexpected html response from %s: %v", err.URL, ErrCloudflareChallenge)
+	}
+	return fmt.Sprintf("unexpected html response from %s: %q", err.URL, err.Snippet)
+}
+
+// Unwrap returns ErrUnexpectedHTML.
+func (err *HTMLError) Unwrap() error {
+	return ErrUnexpectedHTML
+}
+
+// Is returns true for ErrCloudflareChallenge when the page is a Cloudflare
+// challenge.
+func (err *HTMLError) Is(target error) bool {
+	return target == ErrCloudflareChallenge && err.Challenge
+}
+
+// isHTML determines if the content type or the start of the body is html.
+func isHTML(contentType string, r *bufio.Reader) bool {
+	if strings.Contains(contentType, "text/html") {
+		return true
+	}
+	buf, _ := r.Peek(512)
+	buf = bytes.TrimLeft(buf, " \t\r\n")
+	return len(buf) != 0 && buf[0] == '<'
+}
+
+// newHTMLError creates a html error for the url, reading a trimmed snippet
+// from the start of the body.
+func newHTMLError(u *url.URL, r io.Reader) error {
+	buf, _ := io.ReadAll(io.LimitReader(r, 8192))
+	snippet := strings.Join(strings.Fields(htmlTagRE.ReplaceAllString(string(buf), " ")), " ")
+	if len(snippet) > 200 {
+		snippet = strings.ToValidUTF8(snippet[:200], "") + "..."
+	}
+	return &HTMLError{
+		URL:       u.Redacted(),
+		Snippet:   snippet,
+		Challenge: challengeRE.Match(buf),
+	}
+}
+
+// htmlTagRE matches html tags, comments, and script/style blocks.
+var htmlTagRE = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>|<!--.*?-->|<[^>]*>`)
@@ -0,0 +1,173 @@
+package tlapi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// OnCookieExpired is a TL client option to set a func that supplies fresh
+// cookies (for example, cf_clearance) when the Cloudflare clearance cookie
+// has expired, or when a request is rejected with a 403 or a Cloudflare
+// challenge. While the cookies are being refreshed, other requests (such as
+// in-flight pagination) wait for the refresh to complete. A rejected request
+// is retried once after a successful refresh.
+func OnCookieExpired(f func(ctx context.Context) ([]*http.Cookie, error)) Option {
+	return func(cl *Client) {
+		cl.refresh = f
+	}
+}
+
+// SetCookies sets cookies for the site in the client's cookie jar. See
+// NewJar for how the cookies are scoped.
+func (cl *Client) SetCookies(cookies []*http.Cookie) error {
+	u, err := url.Parse(cl.url("/"))
+	if err != nil {
+		return err
+	}
+	setCookies(cl.Jar, u, cookies)
+	cl.Resume()
+	return nil
+}
+
+// NewJar creates a cookie jar containing the cookies for the site's base url.
+//
+// Cookies are scoped to the base url's registrable domain (for example,
+// torrentleech.org), so that they are sent to both the bare domain and any
+// subdomain (such as www). Duplicate cookies (by name) are collapsed, keeping
+// the last, and any host-only cookie with the same name is removed.
+func NewJar(baseURL string, cookies ...*http.Cookie) (http.CookieJar, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	jar, err := cookiejar.New(&cookiejar.Options{
+		PublicSuffixList: publicsuffix.List,
+	})
+	if err != nil {
+		return nil, err
+	}
+	setCookies(jar, u, cookies)
+	return jar, nil
+}
+
+// setCookies sets the cookies in the jar, scoped to the url's registrable
+// domain.
+func setCookies(jar http.CookieJar, u *url.URL, cookies []*http.Cookie) {
+	domain := cookieDomain(u)
+	var v []*http.Cookie
+	seen := make(map[string]int)
+	for _, c := range cookies {
+		cookie := *c
+		cookie.Domain = domain
+		if cookie.Path == "" {
+			cookie.Path = "/"
+		}
+		if i, ok := seen[cookie.Name]; ok {
+			v[i] = &cookie
+			continue
+		}
+		v = append(v, &http.Cookie{
+			Name:   cookie.Name,
+			Path:   cookie.Path,
+			MaxAge: -1,
+		}, &cookie)
+		seen[cookie.Name] = len(v) - 1
+	}
+	jar.SetCookies(u, v)
+}
+
+// copyCookies copies the cookies in the jar for the from url to the to url.
+func copyCookies(jar http.CookieJar, from, to string) error {
+	f, err := url.Parse(from + "/")
+	if err != nil {
+		return err
+	}
+	t, err := url.Parse(to + "/")
+	if err != nil {
+		return err
+	}
+	if cookies := jar.Cookies(f); len(cookies) != 0 {
+		setCookies(jar, t, cookies)
+	}
+	return nil
+}
+
+// cookieDomain returns the registrable domain for the url.
+func cookieDomain(u *url.URL) string {
+	host := u.Hostname()
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	if domain, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return domain
+	}
+	return host
+}
+
+// refreshGen returns the current cookie refresh generation, waiting for any
+// in-progress refresh to complete. Refreshes the cookies first if the
+// clearance cookie is known to have expired.
+func (cl *Client) refreshGen(ctx context.Context) (uint64, error) {
+	cl.refreshMu.RLock()
+	gen, clearance := cl.gen, cl.clearance
+	cl.refreshMu.RUnlock()
+	if clearance.IsZero() || time.Now().Before(clearance) {
+		return gen, nil
+	}
+	if err := cl.refreshCookies(ctx, gen); err != nil {
+		return 0, err
+	}
+	cl.refreshMu.RLock()
+	defer cl.refreshMu.RUnlock()
+	return cl.gen, nil
+}
+
+// refreshCookies refreshes the cookies, unless they have already been
+// refreshed since the generation.
+func (cl *Client) refreshCookies(ctx context.Context, gen uint64) error {
+	cl.refreshMu.Lock()
+	defer cl.refreshMu.Unlock()
+	if cl.gen != gen {
+		return nil
+	}
+	cookies, err := cl.refresh(ctx)
+	if err != nil {
+		return err
+	}
+	if err := cl.SetCookies(cookies); err != nil {
+		return err
+	}
+	cl.gen++
+	cl.clearance = clearanceExpiry(cookies)
+	return nil
+}
+
+// trackClearance tracks the clearance cookie expiry from the response
+// cookies.
+func (cl *Client) trackClearance(cookies []*http.Cookie) {
+	if expires := clearanceExpiry(cookies); !expires.IsZero() {
+		cl.refreshMu.Lock()
+		cl.clearance = expires
+		cl.refreshMu.Unlock()
+	}
+}
+
+// clearanceExpiry returns the expiry of the clearance cookie, if present.
+func clearanceExpiry(cookies []*http.Cookie) time.Time {
+	for _, c := range cookies {
+		switch {
+		case c.Name != "cf_clearance":
+		case c.MaxAge > 0:
+			return time.Now().Add(time.Duration(c.MaxAge) * time.Second)
+		case !c.Expires.IsZero():
+			return c.Expires
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,37 @@
+package tlapi
+
+import (
+	"time"
+)
+
+// WithMinNextDelay is a TL client option to set the minimum delay between
+// search result pages (see SearchRequest.WithNextDelay), overriding shorter
+// request next delays.
+func WithMinNextDelay(d time.Duration) Option {
+	return func(cl *Client) {
+		cl.nextDelay = d
+	}
+}
+
+// pageDelay returns the delay between search result pages, at least the
+// client's minimum next delay.
+func (cl *Client) pageDelay(d time.Duration) time.Duration {
+	if d < cl.nextDelay {
+		return cl.nextDelay
+	}
+	return d
+}
+
+// WithConservativeDefaults is a TL client option to set request pacing to
+// values safe for standard user classes: a budget of 30 requests per
+// minute, at least 10 seconds between search result pages, up to 3 retries
+// of transient failures with backoff starting at 5 seconds, and up to 3
+// rate limit waits. Options following it override its values.
+func WithConservativeDefaults() Option {
+	return func(cl *Client) {
+		cl.limiter = NewBudget(30, time.Minute)
+		cl.nextDelay = 10 * time.Second
+		cl.maxWaits = 3
+		cl.callOpts = append(cl.callOpts, WithCallRetries(3), WithCallBackoff(5*time.Second))
+	}
+}
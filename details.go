@@ -0,0 +1,161 @@
+package tlapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Details are torrent details, scraped from the torrent's details page.
+type Details struct {
+	ID   int    `json:"id"`
+	Name string `json:"name,omitempty"`
+	// Description is the raw description markup (html and/or BBCode).
+	Description string `json:"description,omitempty"`
+	// Poster is the poster image url.
+	Poster string `json:"poster,omitempty"`
+	// Images are the image urls in the description (such as screenshots).
+	Images []string `json:"images,omitempty"`
+	// Promo is the promotion shown on the details page, if any.
+	Promo *Promo `json:"promo,omitempty"`
+	// Nuked is true when the details page marks the torrent as nuked or
+	// retired.
+	Nuked      bool   `json:"nuked,omitempty"`
+	NukeReason string `json:"nukeReason,omitempty"`
+}
+
+// DescriptionText returns the description as plain text.
+func (d Details) DescriptionText() string {
+	return BBCodeText(d.Description)
+}
+
+// DescriptionMarkdown returns the description as Markdown.
+func (d Details) DescriptionMarkdown() string {
+	return BBCodeMarkdown(d.Description)
+}
+
+// Details retrieves the details for the torrent id.
+func (cl *Client) Details(ctx context.Context, id int) (*Details, error) {
+	res, err := cl.Get(ctx, fmt.Sprintf("/torrent/%d", id))
+	if err != nil {
+		return nil, fmt.Errorf("details %d: %w", id, err)
+	}
+	defer res.Body.Close()
+	d, err := ParseDetailsHTML(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("details %d: %w", id, newRequestError(res.Request, err))
+	}
+	d.ID = id
+	return d, nil
+}
+
+// ParseDetailsHTML parses torrent details from a html details page. The
+// description is read from the element with the "description" id or class,
+// the poster from the first image with (or inside an element with) the
+// "poster" class, the promotion from the first promotion banner (see
+// Promo), and the nuke status from the first element with a "nuked" or
+// "retired" class.
+func ParseDetailsHTML(r io.Reader) (*Details, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	d := new(Details)
+	var walk func(*html.Node, bool)
+	walk = func(n *html.Node, poster bool) {
+		if n.Type == html.ElementNode {
+			poster = poster || hasClass(n, "poster")
+			switch {
+			case d.Name == "" && n.DataAtom == atom.H1:
+				d.Name = text(n)
+			case d.Description == "" && (attr(n, "id") == "description" || hasClass(n, "description")):
+				var b strings.Builder
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					if err := html.Render(&b, c); err != nil {
+						return
+					}
+				}
+				d.Description = strings.TrimSpace(b.String())
+				d.Images = append(d.Images, markupImages(parseMarkup(d.Description))...)
+				return
+			case n.DataAtom == atom.Img && poster && d.Poster == "":
+				d.Poster = attr(n, "src")
+			case !d.Nuked && isNuke(n):
+				d.Nuked, d.NukeReason = true, nukeReason(n)
+				return
+			case d.Promo == nil && isPromo(n):
+				if d.Promo = parsePromo(n); d.Promo != nil {
+					return
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, poster)
+		}
+	}
+	walk(doc, false)
+	if d.Name == "" && d.Description == "" {
+		return nil, fmt.Errorf("no torrent details found")
+	}
+	return d, nil
+}
+
+// markupImages returns the image urls in the markup.
+func markupImages(n *markupNode) []string {
+	var v []string
+	if n.tag == "img" {
+		if u := strings.TrimSpace(markupText(n)); u != "" {
+			v = append(v, u)
+		}
+	}
+	for _, c := range n.children {
+		v = append(v, markupImages(c)...)
+	}
+	return v
+}
+
+// missingRE matches site error page messages for deleted or missing
+// torrents.
+var missingRE = regexp.MustCompile(`(?i)\b(not found|does not exist|doesn't exist|no torrent|has been deleted|was deleted)\b`)
+
+// errorHeadingRE matches site error page headings.
+var errorHeadingRE = regexp.MustCompile(`(?i)^(error|oops)\b`)
+
+// Exists checks if the torrent id exists, distinguishing deleted torrents
+// (a 404 or 410 status, or a site error page) from transient failures, which
+// are returned as errors. Pages with a description or a torrent name are
+// never treated as error pages, whatever their text (such as comments)
+// says.
+func (cl *Client) Exists(ctx context.Context, id int) (bool, error) {
+	res, err := cl.Get(ctx, fmt.Sprintf("/torrent/%d", id))
+	var serr *StatusError
+	switch {
+	case errors.As(err, &serr) && (serr.StatusCode == http.StatusNotFound || serr.StatusCode == http.StatusGone):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("exists %d: %w", id, err)
+	}
+	defer res.Body.Close()
+	buf, err := io.ReadAll(res.Body)
+	if err != nil {
+		return false, fmt.Errorf("exists %d: %w", id, newRequestError(res.Request, err))
+	}
+	d, err := ParseDetailsHTML(bytes.NewReader(buf))
+	switch {
+	case err == nil && (d.Description != "" || d.Name != "" && !errorHeadingRE.MatchString(d.Name) && !missingRE.MatchString(d.Name)):
+		return true, nil
+	case missingRE.Match(htmlTagRE.ReplaceAll(buf, []byte(" "))):
+		return false, nil
+	case err == nil:
+		return true, nil
+	}
+	return false, fmt.Errorf("exists %d: %w", id, newRequestError(res.Request, newHTMLError(res.Request.URL, bytes.NewReader(buf))))
+}
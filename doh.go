@@ -0,0 +1,170 @@
+package tlapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// WithDoHResolver is a TL client option to resolve hosts using the DNS over
+// HTTPS (RFC 8484) resolver at the url (for example,
+// "https://cloudflare-dns.com/dns-query"), for use on networks where the
+// site's DNS is blocked or poisoned. Only applies to the default http
+// transport.
+func WithDoHResolver(urlstr string) Option {
+	return func(cl *Client) {
+		cl.doh = urlstr
+	}
+}
+
+// dohResolver is a DNS over HTTPS resolver.
+type dohResolver struct {
+	url    string
+	cl     *http.Client
+	dialer *net.Dialer
+
+	mu    sync.Mutex
+	cache map[string]dohEntry
+}
+
+// dohEntry is a resolved host cache entry.
+type dohEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// newDoHResolver creates a DNS over HTTPS resolver.
+func newDoHResolver(urlstr string, dialer *net.Dialer) *dohResolver {
+	return &dohResolver{
+		url: urlstr,
+		cl: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		dialer: dialer,
+		cache:  make(map[string]dohEntry),
+	}
+}
+
+// DialContext dials the address, resolving the host using the resolver.
+func (r *dohResolver) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return r.dialer.DialContext(ctx, network, addr)
+	}
+	addrs, err := r.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range addrs {
+		var conn net.Conn
+		if conn, err = r.dialer.DialContext(ctx, network, net.JoinHostPort(a, port)); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// lookup returns the addresses for the host, querying A records then AAAA
+// records.
+func (r *dohResolver) lookup(ctx context.Context, host string) ([]string, error) {
+	r.mu.Lock()
+	entry, ok := r.cache[host]
+	r.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+	var addrs []string
+	var ttl uint32
+	for _, typ := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		var err error
+		if addrs, ttl, err = r.query(ctx, host, typ); err != nil {
+			return nil, err
+		}
+		if len(addrs) != 0 {
+			break
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("doh: no addresses for %s", host)
+	}
+	r.mu.Lock()
+	r.cache[host] = dohEntry{
+		addrs:   addrs,
+		expires: time.Now().Add(time.Duration(ttl) * time.Second),
+	}
+	r.mu.Unlock()
+	return addrs, nil
+}
+
+// query queries the resolver for the host's records of the type, returning
+// the addresses and minimum ttl.
+func (r *dohResolver) query(ctx context.Context, host string, typ dnsmessage.Type) ([]string, uint32, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(host, ".") + ".")
+	if err != nil {
+		return nil, 0, err
+	}
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			RecursionDesired: true,
+		},
+		Questions: []dnsmessage.Question{{
+			Name:  name,
+			Type:  typ,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	buf, err := msg.Pack()
+	if err != nil {
+		return nil, 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", r.url, bytes.NewReader(buf))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	res, err := r.cl.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("doh: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("doh: invalid http status %d", res.StatusCode)
+	}
+	if buf, err = io.ReadAll(io.LimitReader(res.Body, 65535)); err != nil {
+		return nil, 0, fmt.Errorf("doh: %w", err)
+	}
+	if err := msg.Unpack(buf); err != nil {
+		return nil, 0, fmt.Errorf("doh: %w", err)
+	}
+	if msg.RCode != dnsmessage.RCodeSuccess {
+		return nil, 0, fmt.Errorf("doh: %s: %v", host, msg.RCode)
+	}
+	var addrs []string
+	ttl := uint32(300)
+	for _, a := range msg.Answers {
+		switch b := a.Body.(type) {
+		case *dnsmessage.AResource:
+			addrs = append(addrs, net.IP(b.A[:]).String())
+		case *dnsmessage.AAAAResource:
+			addrs = append(addrs, net.IP(b.AAAA[:]).String())
+		default:
+			continue
+		}
+		if a.Header.TTL < ttl {
+			ttl = a.Header.TTL
+		}
+	}
+	return addrs, ttl, nil
+}
@@ -0,0 +1,88 @@
+package tlapi
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// dupeSizeTolerance is the relative size difference within which releases of
+// the same content are considered the same size (repacked torrents differ by
+// a few bytes of metadata).
+var dupeSizeTolerance = 0.001
+
+// Duplicate is an existing release matching an upload.
+type Duplicate struct {
+	Torrent Torrent `json:"torrent"`
+	// SameName is true when the release's normalized name matches.
+	SameName bool `json:"sameName,omitempty"`
+	// SameSize is true when the release is the same content (title, year,
+	// episode, and resolution) with the same size.
+	SameSize bool `json:"sameSize,omitempty"`
+}
+
+// CheckDuplicate searches for existing releases that would make an upload of
+// the release name and size (in bytes) a duplicate: releases with the same
+// normalized name, or the same content (title, year, episode, and
+// resolution) with the same size. Returns no duplicates when the upload is
+// not a dupe.
+func (cl *Client) CheckDuplicate(ctx context.Context, name string, size int64) ([]Duplicate, error) {
+	r := ParseRelease(name)
+	terms := strings.Fields(NormalizeTitle(r.Title))
+	if len(terms) == 0 {
+		terms = strings.Fields(NormalizeTitle(name))
+	}
+	if r.Year != 0 {
+		terms = append(terms, strconv.Itoa(r.Year))
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("check duplicate %q: empty name", name)
+	}
+	torrents, err := searchAll(ctx, cl, Search(terms...))
+	if err != nil {
+		return nil, fmt.Errorf("check duplicate %q: %w", name, err)
+	}
+	key := dupeName(name)
+	var dupes []Duplicate
+	for _, t := range torrents {
+		d := Duplicate{
+			Torrent:  t,
+			SameName: dupeName(t.Name) == key,
+			SameSize: sameRelease(r, ParseRelease(t.Name)) && sameSize(size, t.Size),
+		}
+		if d.SameName || d.SameSize {
+			dupes = append(dupes, d)
+		}
+	}
+	return dupes, nil
+}
+
+// dupeName returns the normalized release name for duplicate checks.
+func dupeName(name string) string {
+	name = strings.TrimSpace(name)
+	for _, ext := range []string{".torrent", ".mkv", ".mp4", ".avi"} {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			name = name[:len(name)-len(ext)]
+		}
+	}
+	return NormalizeTitle(name)
+}
+
+// sameRelease determines if the releases are the same content.
+func sameRelease(a, b Release) bool {
+	return NormalizeTitle(a.Title) == NormalizeTitle(b.Title) &&
+		a.Year == b.Year &&
+		a.Season == b.Season &&
+		a.Episode == b.Episode &&
+		a.Resolution == b.Resolution
+}
+
+// sameSize determines if the sizes are within the duplicate size tolerance.
+func sameSize(a, b int64) bool {
+	if a <= 0 || b <= 0 {
+		return false
+	}
+	return math.Abs(float64(a-b)) <= dupeSizeTolerance*float64(a)
+}
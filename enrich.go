@@ -0,0 +1,121 @@
+package tlapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Enriched is a torrent with metadata attached from external sources.
+type Enriched struct {
+	Torrent
+	TMDB    *TMDBMetadata `json:"tmdb,omitempty"`
+	Episode *EpisodeInfo  `json:"episode,omitempty"`
+	Game    *GameMetadata `json:"game,omitempty"`
+}
+
+// Enrich wraps the torrents for enrichment.
+func Enrich(torrents []Torrent) []Enriched {
+	v := make([]Enriched, len(torrents))
+	for i, t := range torrents {
+		v[i].Torrent = t
+	}
+	return v
+}
+
+// TMDBMetadata is TMDB movie or TV show metadata.
+type TMDBMetadata struct {
+	ID          int           `json:"id"`
+	MediaType   string        `json:"mediaType,omitempty"`
+	Title       string        `json:"title,omitempty"`
+	Overview    string        `json:"overview,omitempty"`
+	PosterURL   string        `json:"posterURL,omitempty"`
+	Runtime     time.Duration `json:"runtime,omitempty"`
+	ReleaseDate time.Time     `json:"releaseDate,omitempty"`
+}
+
+// TMDBClient is the interface for TMDB clients. Users supply their own
+// implementation (and TMDB credentials).
+type TMDBClient interface {
+	// FindByIMDbID returns the TMDB metadata for the IMDb id (for example,
+	// "tt0137523"), or nil when not found.
+	FindByIMDbID(ctx context.Context, imdbID string) (*TMDBMetadata, error)
+}
+
+// EnrichTMDB attaches TMDB metadata to the torrents with an IMDb id, looking
+// up each distinct id once.
+func EnrichTMDB(ctx context.Context, c TMDBClient, v []Enriched) error {
+	cache := make(map[string]*TMDBMetadata)
+	for i := range v {
+		id := NormalizeIMDbID(v[i].ImdbID)
+		if id == "" {
+			continue
+		}
+		m, ok := cache[id]
+		if !ok {
+			var err error
+			if m, err = c.FindByIMDbID(ctx, id); err != nil {
+				return fmt.Errorf("tmdb %s: %w", id, err)
+			}
+			cache[id] = m
+		}
+		v[i].TMDB = m
+	}
+	return nil
+}
+
+// NormalizeIMDbID normalizes an IMDb id to its "tt" prefixed form, returning
+// an empty string for empty or zero ids.
+func NormalizeIMDbID(id string) string {
+	id = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(id)), "tt")
+	if strings.TrimLeft(id, "0") == "" {
+		return ""
+	}
+	if len(id) < 7 {
+		id = strings.Repeat("0", 7-len(id)) + id
+	}
+	return "tt" + id
+}
+
+// GameMetadata is IGDB game metadata.
+type GameMetadata struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name,omitempty"`
+	Summary     string    `json:"summary,omitempty"`
+	Platforms   []string  `json:"platforms,omitempty"`
+	ReleaseDate time.Time `json:"releaseDate,omitempty"`
+	CoverURL    string    `json:"coverURL,omitempty"`
+}
+
+// IGDBClient is the interface for IGDB clients. Users supply their own
+// implementation (and IGDB credentials).
+type IGDBClient interface {
+	// Game returns the IGDB metadata for the game id, or nil when not found.
+	Game(ctx context.Context, id string) (*GameMetadata, error)
+}
+
+// EnrichIGDB attaches IGDB metadata to the game category torrents with an
+// IGDB id, looking up each distinct id once.
+func EnrichIGDB(ctx context.Context, c IGDBClient, v []Enriched) error {
+	cache := make(map[string]*GameMetadata)
+	for i := range v {
+		id := strings.TrimSpace(v[i].IgdbID)
+		if id == "" || id == "0" {
+			continue
+		}
+		if cat, ok := v[i].Category(); !ok || cat.Kind != KindGame {
+			continue
+		}
+		m, ok := cache[id]
+		if !ok {
+			var err error
+			if m, err = c.Game(ctx, id); err != nil {
+				return fmt.Errorf("igdb %s: %w", id, err)
+			}
+			cache[id] = m
+		}
+		v[i].Game = m
+	}
+	return nil
+}
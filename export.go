@@ -0,0 +1,119 @@
+package tlapi
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PageError is a search page error.
+type PageError struct {
+	Page int
+	Err  error
+}
+
+// Error satisfies the error interface. The page is not repeated, as search
+// errors already include the page.
+func (err *PageError) Error() string {
+	return err.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (err *PageError) Unwrap() error {
+	return err.Err
+}
+
+// PageErrors are search page errors.
+type PageErrors []*PageError
+
+// Error satisfies the error interface.
+func (errs PageErrors) Error() string {
+	v := make([]string, len(errs))
+	for i, err := range errs {
+		v[i] = err.Error()
+	}
+	return fmt.Sprintf("%d page(s) failed: %s", len(errs), strings.Join(v, "; "))
+}
+
+// Export retrieves all pages of the search request, fetching up to workers
+// pages concurrently, and returns the torrents in stable (page, index) order.
+// Successive page fetches are started no closer together than the request's
+// next delay.
+//
+// When one or more pages (other than the first) fail, the torrents from the
+// successful pages are returned along with a PageErrors error.
+func (req *SearchRequest) Export(ctx context.Context, cl *Client, workers int) ([]Torrent, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	first := req.Page
+	if first == 0 {
+		first = 1
+	}
+	res, err := req.WithPage(first).Do(ctx, cl)
+	if err != nil {
+		return nil, &PageError{Page: first, Err: err}
+	}
+	last := first
+	if res.PerPage != 0 && (res.NumFound+res.PerPage-1)/res.PerPage > first {
+		last = (res.NumFound + res.PerPage - 1) / res.PerPage
+	}
+	pages := make([][]Torrent, last-first+1)
+	pages[0] = res.TorrentList
+	var errs PageErrors
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ch := make(chan int)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for page := range ch {
+				res, err := req.WithPage(page).Do(ctx, cl)
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, &PageError{Page: page, Err: err})
+				} else {
+					pages[page-first] = res.TorrentList
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	var ctxErr error
+	d := cl.pageDelay(req.d)
+loop:
+	for page := first + 1; page <= last; page++ {
+		if d != 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(d):
+			}
+		}
+		select {
+		case <-ctx.Done():
+			ctxErr = ctx.Err()
+			break loop
+		case ch <- page:
+		}
+	}
+	close(ch)
+	wg.Wait()
+	var torrents []Torrent
+	for _, v := range pages {
+		torrents = append(torrents, v...)
+	}
+	if ctxErr != nil {
+		return torrents, ctxErr
+	}
+	if len(errs) != 0 {
+		sort.Slice(errs, func(i, j int) bool {
+			return errs[i].Page < errs[j].Page
+		})
+		return torrents, errs
+	}
+	return torrents, nil
+}
@@ -0,0 +1,331 @@
+package tlapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RangeItem is a facet range bucket.
+type RangeItem struct {
+	// Key is the raw facet item key (for example, "[0 TO 50]"), suitable for
+	// use as a facet filter value.
+	Key   string
+	Label string
+	// Lower and Upper are the parsed (inclusive) bucket bounds. An open
+	// ("*") bound is math.MinInt64 or math.MaxInt64, respectively. Date
+	// bounds (for example, "NOW/HOUR-14DAYS") are parsed as an offset in
+	// seconds from the current time.
+	Lower int64
+	Upper int64
+	Count int
+	// Parsed is true when both bounds were parsed.
+	Parsed bool
+}
+
+// Ranges returns the facet items as range buckets, ordered by their bounds.
+// Items with keys that could not be parsed are ordered last, by key.
+func (f Facet) Ranges() []RangeItem {
+	v := make([]RangeItem, 0, len(f.Items))
+	for key, item := range f.Items {
+		lower, upper, ok := ParseRange(key)
+		v = append(v, RangeItem{
+			Key:    key,
+			Label:  item.Label,
+			Lower:  lower,
+			Upper:  upper,
+			Count:  item.Count,
+			Parsed: ok,
+		})
+	}
+	sort.Slice(v, func(i, j int) bool {
+		switch {
+		case v[i].Parsed != v[j].Parsed:
+			return v[i].Parsed
+		case !v[i].Parsed || v[i].Lower == v[j].Lower && v[i].Upper == v[j].Upper:
+			return v[i].Key < v[j].Key
+		case v[i].Lower != v[j].Lower:
+			return v[i].Lower < v[j].Lower
+		}
+		return v[i].Upper < v[j].Upper
+	})
+	return v
+}
+
+// Count is a facet item count.
+type Count struct {
+	Key   string
+	Count int
+}
+
+// Sorted returns the tag items ordered by count (descending), then by key.
+func (t Tags) Sorted() []Count {
+	return sortCounts(t.Items)
+}
+
+// CategoryCount is a category facet item count.
+type CategoryCount struct {
+	ID    int
+	Count int
+}
+
+// Sorted returns the category items ordered by count (descending), then by
+// id. Items with non-numeric keys are skipped.
+func (f FacetID) Sorted() []CategoryCount {
+	v := make([]CategoryCount, 0, len(f.Items))
+	for key, count := range f.Items {
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		v = append(v, CategoryCount{ID: id, Count: count})
+	}
+	sort.Slice(v, func(i, j int) bool {
+		if v[i].Count != v[j].Count {
+			return v[i].Count > v[j].Count
+		}
+		return v[i].ID < v[j].ID
+	})
+	return v
+}
+
+// sortCounts returns the items ordered by count (descending), then by key.
+func sortCounts(items map[string]int) []Count {
+	v := make([]Count, 0, len(items))
+	for key, count := range items {
+		v = append(v, Count{Key: key, Count: count})
+	}
+	sort.Slice(v, func(i, j int) bool {
+		if v[i].Count != v[j].Count {
+			return v[i].Count > v[j].Count
+		}
+		return v[i].Key < v[j].Key
+	})
+	return v
+}
+
+// ParseRange parses a facet range (for example, "[0 TO 50]", "[201 TO *]",
+// or "[NOW/HOUR-14DAYS TO NOW/HOUR+1HOUR]") into its lower and upper bounds.
+// See RangeItem for how open and date bounds are represented.
+func ParseRange(s string) (int64, int64, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '[' || s[len(s)-1] != ']' {
+		return 0, 0, false
+	}
+	a, b, ok := strings.Cut(s[1:len(s)-1], " TO ")
+	if !ok {
+		return 0, 0, false
+	}
+	lower, ok := parseBound(a, math.MinInt64)
+	if !ok {
+		return 0, 0, false
+	}
+	upper, ok := parseBound(b, math.MaxInt64)
+	if !ok {
+		return 0, 0, false
+	}
+	return lower, upper, true
+}
+
+// parseBound parses a range bound, returning open for "*".
+func parseBound(s string, open int64) (int64, bool) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "*":
+		return open, true
+	case strings.HasPrefix(s, "NOW"):
+		return parseDateMath(s[3:])
+	}
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return i, true
+}
+
+// dateMathRE matches date math operations.
+var dateMathRE = regexp.MustCompile(`^([/+-])(\d*)([A-Z]+)`)
+
+// parseDateMath parses the date math operations following NOW as an offset in
+// seconds. Rounding operations (/UNIT) are ignored.
+func parseDateMath(s string) (int64, bool) {
+	var d int64
+	for s != "" {
+		m := dateMathRE.FindStringSubmatch(s)
+		if m == nil {
+			return 0, false
+		}
+		s = s[len(m[0]):]
+		unit, ok := dateMathUnits[strings.TrimSuffix(m[3], "S")]
+		if !ok {
+			return 0, false
+		}
+		if m[1] == "/" {
+			continue
+		}
+		n, err := strconv.ParseInt(m[2], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		if m[1] == "-" {
+			n = -n
+		}
+		d += n * int64(unit/time.Second)
+	}
+	return d, true
+}
+
+// dateMathUnits are the date math units.
+var dateMathUnits = map[string]time.Duration{
+	"SECOND": time.Second,
+	"MINUTE": time.Minute,
+	"HOUR":   time.Hour,
+	"DAY":    24 * time.Hour,
+	"WEEK":   7 * 24 * time.Hour,
+	"MONTH":  30 * 24 * time.Hour,
+	"YEAR":   365 * 24 * time.Hour,
+}
+
+// FacetsWOC are search response facets "without current" counts: each
+// facet's counts as if its own filter were not applied. Facets not modeled
+// by SearchFacets are kept in Other.
+type FacetsWOC struct {
+	SearchFacets
+	Other map[string]Tags `json:"-"`
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface. Range facets are
+// decoded from either labeled items or plain counts, and missing, null, or
+// reshaped facets are tolerated (see SearchFacets.UnmarshalJSON).
+func (f *FacetsWOC) UnmarshalJSON(buf []byte) error {
+	*f = FacetsWOC{}
+	other, err := f.SearchFacets.decode(buf)
+	if err != nil {
+		return fmt.Errorf("facetswoc: %w", err)
+	}
+	for key, raw := range other {
+		var t Tags
+		if decodeSection(raw, &t, unmarshal[Tags]) == nil {
+			if f.Other == nil {
+				f.Other = make(map[string]Tags)
+			}
+			f.Other[key] = t
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface. Responses for some
+// user classes omit or reshape the facets, so null or empty (including
+// empty array) facets are left empty, and facets that cannot be decoded are
+// skipped instead of failing the response (see SearchResponse.HasFacets).
+func (f *SearchFacets) UnmarshalJSON(buf []byte) error {
+	*f = SearchFacets{}
+	_, err := f.decode(buf)
+	return err
+}
+
+// decode decodes the facets leniently, returning the facets not modeled by
+// search facets.
+func (f *SearchFacets) decode(buf []byte) (map[string]json.RawMessage, error) {
+	if isEmptyJSON(buf) {
+		return nil, nil
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return nil, err
+	}
+	other := make(map[string]json.RawMessage)
+	for key, raw := range m {
+		switch key {
+		case "categoryID":
+			_ = decodeSection(raw, &f.CategoryID, unmarshal[FacetID])
+		case "tags":
+			_ = decodeSection(raw, &f.Tags, unmarshal[Tags])
+		case "added":
+			_ = decodeSection(raw, &f.Added, decodeFacet)
+		case "name":
+			_ = decodeSection(raw, &f.Name, decodeFacet)
+		case "seeders":
+			_ = decodeSection(raw, &f.Seeders, decodeFacet)
+		case "size":
+			_ = decodeSection(raw, &f.Size, decodeFacet)
+		default:
+			other[key] = raw
+		}
+	}
+	return other, nil
+}
+
+// emptyItemsRE matches a facet's empty items array (as encoded for an empty
+// map by the site).
+var emptyItemsRE = regexp.MustCompile(`("items"\s*:\s*)\[\s*\]`)
+
+// decodeSection decodes a facet section with the decode func, leaving v
+// unchanged when the section is empty or cannot be decoded.
+func decodeSection[T any](buf []byte, v *T, decode func([]byte, *T) error) error {
+	if isEmptyJSON(buf) {
+		return nil
+	}
+	var section T
+	err := decode(buf, &section)
+	if err != nil {
+		if err = decode(emptyItemsRE.ReplaceAll(buf, []byte("${1}{}")), &section); err != nil {
+			return err
+		}
+	}
+	*v = section
+	return nil
+}
+
+// unmarshal unmarshals the json into v.
+func unmarshal[T any](buf []byte, v *T) error {
+	return json.Unmarshal(buf, v)
+}
+
+// isEmptyJSON determines if the json value is null, an empty array, or an
+// empty object.
+func isEmptyJSON(buf []byte) bool {
+	switch s := strings.Join(strings.Fields(string(buf)), ""); s {
+	case "", "null", "[]", "{}":
+		return true
+	}
+	return false
+}
+
+// HasFacets returns true when the response includes any facets.
+func (res *SearchResponse) HasFacets() bool {
+	f := res.Facets
+	return len(f.CategoryID.Items) != 0 ||
+		len(f.Added.Items) != 0 ||
+		len(f.Name.Items) != 0 ||
+		len(f.Seeders.Items) != 0 ||
+		len(f.Size.Items) != 0 ||
+		len(f.Tags.Items) != 0
+}
+
+// decodeFacet decodes a facet with either labeled items or plain counts.
+func decodeFacet(buf []byte, f *Facet) error {
+	if err := json.Unmarshal(buf, f); err == nil {
+		return nil
+	}
+	var t Tags
+	if err := json.Unmarshal(buf, &t); err != nil {
+		return err
+	}
+	*f = Facet{
+		Name:  t.Name,
+		Title: t.Title,
+		Type:  t.Type,
+		Items: make(map[string]Item, len(t.Items)),
+	}
+	for key, count := range t.Items {
+		f.Items[key] = Item{Label: key, Count: count}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package tlapi
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxFilenameLen is the maximum length (in bytes) of sanitized filenames.
+const MaxFilenameLen = 240
+
+// SanitizeFilename returns the name as a filename safe for use on common
+// filesystems (including Windows and NTFS mounts). Path separators and
+// characters reserved on Windows are replaced with '_', control characters
+// are removed, trailing dots and spaces are trimmed, reserved device names
+// (such as "CON" or "LPT1") are prefixed with '_', and the name is truncated
+// to MaxFilenameLen bytes, keeping its extension.
+func SanitizeFilename(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case strings.ContainsRune(`/\:*?"<>|`, r):
+			return '_'
+		case unicode.IsControl(r), r == utf8.RuneError:
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimRight(strings.TrimSpace(name), ". ")
+	if len(name) > MaxFilenameLen {
+		ext := filepath.Ext(name)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		base := name[:MaxFilenameLen-len(ext)]
+		for !utf8.ValidString(base) {
+			base = base[:len(base)-1]
+		}
+		name = strings.TrimRight(base, ". ") + ext
+	}
+	if isReservedFilename(name) {
+		name = "_" + name
+	}
+	if name == "" {
+		return "_"
+	}
+	return name
+}
+
+// isReservedFilename determines if the name is a reserved Windows device
+// name, with or without an extension.
+func isReservedFilename(name string) bool {
+	base := strings.ToUpper(strings.TrimSpace(strings.SplitN(name, ".", 2)[0]))
+	switch base {
+	case "CON", "PRN", "AUX", "NUL":
+		return true
+	}
+	if len(base) == 4 && (strings.HasPrefix(base, "COM") || strings.HasPrefix(base, "LPT")) {
+		return '1' <= base[3] && base[3] <= '9'
+	}
+	return false
+}
+
+// SaveTorrent downloads the torrent's metainfo and saves it in the
+// directory, returning the saved path. The filename is the sanitized
+// torrent filename, falling back to the name or id.
+func (cl *Client) SaveTorrent(ctx context.Context, t Torrent, dir string) (string, error) {
+	buf, err := cl.Torrent(ctx, t.ID)
+	if err != nil {
+		return "", err
+	}
+	name := torrentFilename(t)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	p := filepath.Join(dir, name)
+	if err := os.Rename(f.Name(), p); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// torrentFilename returns the sanitized .torrent file name for the torrent,
+// from its file name, name, or id.
+func torrentFilename(t Torrent) string {
+	name := t.Filename
+	switch {
+	case name == "" && t.Name != "":
+		name = t.Name
+	case name == "":
+		name = strconv.Itoa(t.ID)
+	}
+	if !strings.HasSuffix(strings.ToLower(name), ".torrent") {
+		name += ".torrent"
+	}
+	return SanitizeFilename(name)
+}
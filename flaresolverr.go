@@ -0,0 +1,127 @@
+package tlapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WithFlareSolverr is a TL client option to refresh the Cloudflare clearance
+// cookies (cf_clearance) using a FlareSolverr instance at the url (for
+// example, "http://localhost:8191"). The site's challenge page is solved by
+// FlareSolverr when the clearance cookie has expired or a request is
+// rejected with a 403 or a Cloudflare challenge, after which the jar is
+// updated with the fresh Cloudflare cookies and the request retried (see
+// OnCookieExpired, which this option replaces). As clearance cookies are
+// bound to the browser's user agent, subsequent requests are sent with
+// FlareSolverr's user agent.
+func WithFlareSolverr(urlstr string) Option {
+	return func(cl *Client) {
+		urlstr = strings.TrimSuffix(urlstr, "/")
+		cl.refresh = func(ctx context.Context) ([]*http.Cookie, error) {
+			return cl.flareSolverr(ctx, urlstr)
+		}
+	}
+}
+
+// flareSolverrTimeout is the maximum time FlareSolverr is given to solve a
+// challenge.
+var flareSolverrTimeout = 60 * time.Second
+
+// flareSolverrResponse is a FlareSolverr response.
+type flareSolverrResponse struct {
+	Status   string `json:"status"`
+	Message  string `json:"message"`
+	Solution struct {
+		Status  int `json:"status"`
+		Cookies []struct {
+			Name     string  `json:"name"`
+			Value    string  `json:"value"`
+			Path     string  `json:"path"`
+			Expires  float64 `json:"expires"`
+			HTTPOnly bool    `json:"httpOnly"`
+			Secure   bool    `json:"secure"`
+		} `json:"cookies"`
+		UserAgent string `json:"userAgent"`
+	} `json:"solution"`
+}
+
+// flareSolverr solves the site's challenge page with the FlareSolverr
+// instance, returning the Cloudflare cookies from the solution.
+func (cl *Client) flareSolverr(ctx context.Context, urlstr string) ([]*http.Cookie, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"cmd":        "request.get",
+		"url":        cl.url("/"),
+		"maxTimeout": flareSolverrTimeout.Milliseconds(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", urlstr+"/v1", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// allow time past the solve timeout for the browser to start and respond
+	hc := &http.Client{
+		Transport: cl.Transport,
+		Timeout:   flareSolverrTimeout + 30*time.Second,
+	}
+	res, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("flaresolverr: %w", err)
+	}
+	defer res.Body.Close()
+	var v flareSolverrResponse
+	if err := json.NewDecoder(res.Body).Decode(&v); err != nil {
+		return nil, fmt.Errorf("flaresolverr: invalid http status %d: %w", res.StatusCode, err)
+	}
+	if v.Status != "ok" {
+		return nil, fmt.Errorf("flaresolverr: %s: %s", v.Status, v.Message)
+	}
+	var cookies []*http.Cookie
+	for _, c := range v.Solution.Cookies {
+		// only keep the cloudflare cookies, so that the solver's (logged
+		// out) session does not replace the client's
+		if !strings.HasPrefix(c.Name, "cf_") && !strings.HasPrefix(c.Name, "__cf") {
+			continue
+		}
+		cookie := &http.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			HttpOnly: c.HTTPOnly,
+			Secure:   c.Secure,
+		}
+		if c.Expires > 0 {
+			sec, frac := math.Modf(c.Expires)
+			cookie.Expires = time.Unix(int64(sec), int64(frac*1e9))
+		}
+		cookies = append(cookies, cookie)
+	}
+	if len(cookies) == 0 {
+		return nil, errors.New("flaresolverr: no clearance cookies in solution")
+	}
+	if v.Solution.UserAgent != "" {
+		// refreshMu is held by refreshCookies
+		cl.solverAgent = v.Solution.UserAgent
+	}
+	return cookies, nil
+}
+
+// agent returns the user agent for requests, preferring the user agent that
+// solved the site's challenge.
+func (cl *Client) agent() string {
+	cl.refreshMu.RLock()
+	defer cl.refreshMu.RUnlock()
+	if cl.solverAgent != "" {
+		return cl.solverAgent
+	}
+	return cl.userAgent
+}
@@ -0,0 +1,340 @@
+//go:build ignore
+
+// gen.go fetches the live browse facets and category menu, and regenerates
+// categories_gen.go with the category constants, registry entries, and tags
+// for the site categories and tags missing from the hand-maintained registry
+// and known tags.
+//
+// Usage:
+//
+//	TL_SESSID=... TL_UID=... TL_PASS=... go generate
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moistari/tlapi"
+	"golang.org/x/net/html"
+)
+
+func main() {
+	if err := run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context) error {
+	sessID, uid, pass := os.Getenv("TL_SESSID"), os.Getenv("TL_UID"), os.Getenv("TL_PASS")
+	if sessID == "" || uid == "" || pass == "" {
+		return fmt.Errorf("TL_SESSID, TL_UID, and TL_PASS must be set")
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	cl := tlapi.New(tlapi.WithCreds(sessID, uid, pass))
+	res, err := tlapi.Search().Do(ctx, cl)
+	if err != nil {
+		return err
+	}
+	menu, err := categoryMenu(ctx, cl)
+	if err != nil {
+		return err
+	}
+	gen, err := generatedIDs("categories_gen.go")
+	if err != nil {
+		return err
+	}
+	consts, err := constNames("categories_gen.go")
+	if err != nil {
+		return err
+	}
+	// categories
+	var cats []category
+	for _, c := range res.Facets.CategoryID.Sorted() {
+		if _, ok := tlapi.CategoryByID(c.ID); ok && !gen[c.ID] {
+			continue
+		}
+		info := menu[c.ID]
+		info.ID = c.ID
+		if info.Name == "" {
+			info.Name = "Category " + strconv.Itoa(c.ID)
+		}
+		info.Kind = guessKind(info.Group, info.Name)
+		if info.Group == "" {
+			info.Group = guessGroup(info.Name, info.Kind)
+		}
+		cats = append(cats, category{CategoryInfo: info, Const: constName(consts, info)})
+	}
+	sort.Slice(cats, func(i, j int) bool { return cats[i].ID < cats[j].ID })
+	// tags
+	known := make(map[string]bool)
+	for _, tag := range tlapi.KnownTags {
+		known[strings.ToLower(tag)] = true
+	}
+	var tags []string
+	for _, c := range res.Facets.Tags.Sorted() {
+		if !known[strings.ToLower(c.Key)] {
+			tags = append(tags, c.Key)
+		}
+	}
+	sort.Strings(tags)
+	return write("categories_gen.go", cats, tags)
+}
+
+// category is a generated category.
+type category struct {
+	tlapi.CategoryInfo
+	Const string
+}
+
+// generatedConstRE matches the generated category constants.
+var generatedConstRE = regexp.MustCompile(`(?m)^\s*Category\w+\s*=\s*(\d+)\s*$`)
+
+// generatedIDs returns the category ids in the current generated file, which
+// are regenerated.
+func generatedIDs(name string) (map[int]bool, error) {
+	buf, err := os.ReadFile(name)
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	ids := make(map[int]bool)
+	for _, m := range generatedConstRE.FindAllSubmatch(buf, -1) {
+		id, _ := strconv.Atoi(string(m[1]))
+		ids[id] = true
+	}
+	return ids, nil
+}
+
+// constNames returns the package's category constant names, excluding the
+// generated file.
+func constNames(generated string) (map[string]bool, error) {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), ".", nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for name, f := range pkgs["tlapi"].Files {
+		if name == generated {
+			continue
+		}
+		for _, obj := range f.Scope.Objects {
+			if obj.Kind == ast.Con && strings.HasPrefix(obj.Name, "Category") {
+				names[obj.Name] = true
+			}
+		}
+	}
+	return names, nil
+}
+
+// constName returns a unique constant name for the category (for example,
+// CategoryGamesPS6), adding it to names.
+func constName(names map[string]bool, c tlapi.CategoryInfo) string {
+	name := "Category" + ident(c.Group)
+	if c.Name != c.Group {
+		name += ident(c.Name)
+	}
+	if names[name] {
+		name += strconv.Itoa(c.ID)
+	}
+	names[name] = true
+	return name
+}
+
+// ident converts s to an exported identifier fragment.
+func ident(s string) string {
+	var b strings.Builder
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(f[:1]) + f[1:])
+	}
+	return b.String()
+}
+
+// categoryRE matches category links.
+var categoryRE = regexp.MustCompile(`/categories/(\d+)$`)
+
+// categoryMenu returns the category names and groups from the browse page's
+// category menu, where each group is a menu item with a nested list of
+// category links.
+func categoryMenu(ctx context.Context, cl *tlapi.Client) (map[int]tlapi.CategoryInfo, error) {
+	res, err := cl.Get(ctx, "/torrents/browse")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	return parseCategoryMenu(res.Body)
+}
+
+// parseCategoryMenu parses the category names and groups from the category
+// menu.
+func parseCategoryMenu(r io.Reader) (map[int]tlapi.CategoryInfo, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	menu := make(map[int]tlapi.CategoryInfo)
+	var walk func(*html.Node, string)
+	walk = func(n *html.Node, group string) {
+		if n.Type == html.ElementNode && n.Data == "li" {
+			if s := groupLabel(n); s != "" {
+				group = s
+			}
+		}
+		if n.Type == html.ElementNode && n.Data == "a" {
+			for _, a := range n.Attr {
+				if m := categoryRE.FindStringSubmatch(a.Val); a.Key == "href" && m != nil {
+					id, _ := strconv.Atoi(m[1])
+					if s := text(n); s != "" && menu[id].Name == "" {
+						menu[id] = tlapi.CategoryInfo{Name: s, Group: group}
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, group)
+		}
+	}
+	walk(doc, "")
+	return menu, nil
+}
+
+// groupLabel returns the label of a menu item with a nested list (the text
+// of the item's children before the list), or an empty string.
+func groupLabel(li *html.Node) string {
+	var label []string
+	for c := li.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "ul" {
+			return strings.Join(label, " ")
+		}
+		if s := text(c); s != "" {
+			label = append(label, s)
+		}
+	}
+	return ""
+}
+
+// text returns the node's collapsed text content.
+func text(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data + " ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// guessKind guesses the media kind from the category group and name.
+func guessKind(group, name string) tlapi.Kind {
+	s := strings.ToLower(name)
+	switch {
+	case group == tlapi.GroupGames:
+		return tlapi.KindGame
+	case group == tlapi.GroupApps:
+		return tlapi.KindApp
+	case group == tlapi.GroupBooks:
+		return tlapi.KindBook
+	case containsAny(s, "xbox", "ps", "nintendo", "wii", "switch", "game"):
+		return tlapi.KindGame
+	case containsAny(s, "iso", "0-day", "mobile", "app"):
+		return tlapi.KindApp
+	case containsAny(s, "book", "comic"):
+		return tlapi.KindBook
+	case containsAny(s, "audio", "flac", "mp3"):
+		return tlapi.KindAudio
+	}
+	return tlapi.KindVideo
+}
+
+// guessGroup guesses the category group from the category name and media
+// kind, for categories not in a menu group.
+func guessGroup(name string, kind tlapi.Kind) string {
+	s := strings.ToLower(name)
+	switch {
+	case kind == tlapi.KindGame:
+		return tlapi.GroupGames
+	case kind == tlapi.KindApp:
+		return tlapi.GroupApps
+	case kind == tlapi.KindBook:
+		return tlapi.GroupBooks
+	case kind == tlapi.KindAudio || containsAny(s, "music"):
+		return tlapi.GroupMusic
+	case containsAny(s, "foreign"):
+		return tlapi.GroupForeign
+	case containsAny(s, "anime", "cartoon", "animation"):
+		return tlapi.GroupAnimation
+	case containsAny(s, "education", "tutorial"):
+		return tlapi.GroupEducation
+	case containsAny(s, "tv", "episode", "series"):
+		return tlapi.GroupTV
+	}
+	return tlapi.GroupMovies
+}
+
+// containsAny determines if s contains any of the substrings.
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// write writes the generated file.
+func write(name string, cats []category, tags []string) error {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gen.go; DO NOT EDIT.\n\npackage tlapi\n\n")
+	if len(cats) != 0 {
+		b.WriteString("// Generated categories.\nconst (\n")
+		for _, c := range cats {
+			fmt.Fprintf(&b, "%s = %d\n", c.Const, c.ID)
+		}
+		b.WriteString(")\n\n")
+	}
+	b.WriteString("// genCategories are site categories found by the generator that are not in\n// the hand-maintained category registry.\n")
+	b.WriteString("var genCategories = []CategoryInfo{\n")
+	for _, c := range cats {
+		fmt.Fprintf(&b, "{%s, %q, %q, %q},\n", c.Const, c.Name, c.Group, c.Kind)
+	}
+	b.WriteString("}\n\n")
+	b.WriteString("// genTags are site tags found by the generator that are not in the\n// hand-maintained known tags.\n")
+	b.WriteString("var genTags = []string{\n")
+	for _, tag := range tags {
+		fmt.Fprintf(&b, "%q,\n", tag)
+	}
+	b.WriteString("}\n")
+	buf, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+	for _, c := range cats {
+		log.Printf("category %d %q not in registry", c.ID, c.Name)
+	}
+	for _, tag := range tags {
+		log.Printf("tag %q not in known tags", tag)
+	}
+	return os.WriteFile(name, buf, 0o644)
+}
@@ -0,0 +1,47 @@
+package tlapi
+
+import (
+	"path"
+	"strings"
+)
+
+// GroupFilter is a release group allow and deny list. Groups are parsed
+// from torrent names, and patterns are matched case-insensitively, with
+// support for '*' and '?' wildcards (for example, "FraMeSToR" or "*-HDH").
+type GroupFilter struct {
+	// Allow are the allowed group patterns. When not empty, only torrents
+	// from matching groups are allowed.
+	Allow []string `json:"allow,omitempty"`
+	// Deny are the denied group patterns. Denied groups take precedence
+	// over allowed groups.
+	Deny []string `json:"deny,omitempty"`
+}
+
+// Match returns true when the torrent's release group is allowed. Suitable
+// for use with SearchRequest.WithFilter.
+func (f GroupFilter) Match(t Torrent) bool {
+	return f.MatchGroup(ParseRelease(t.Name).Group)
+}
+
+// MatchGroup returns true when the release group is allowed.
+func (f GroupFilter) MatchGroup(group string) bool {
+	if matchGroup(f.Deny, group) {
+		return false
+	}
+	return len(f.Allow) == 0 || matchGroup(f.Allow, group)
+}
+
+// matchGroup returns true when the group matches any of the patterns.
+func matchGroup(patterns []string, group string) bool {
+	if group == "" {
+		return false
+	}
+	group = strings.ToLower(group)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if ok, err := path.Match(pattern, group); ok || err != nil && pattern == group {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package tlapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is the not found error.
+var ErrNotFound = errors.New("not found")
+
+// HashEntry is a hash index entry, mapping a torrent id to its info hash.
+type HashEntry struct {
+	ID       int    `json:"id"`
+	InfoHash string `json:"infoHash"`
+	Name     string `json:"name,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+}
+
+// HashIndex is a local index of torrent ids and info hashes, built from
+// downloaded metainfo. A hash index is safe for concurrent use.
+type HashIndex struct {
+	mu     sync.RWMutex
+	hashes map[string]HashEntry
+	ids    map[int]string
+}
+
+// NewHashIndex creates a hash index containing the entries.
+func NewHashIndex(entries ...HashEntry) *HashIndex {
+	idx := &HashIndex{
+		hashes: make(map[string]HashEntry),
+		ids:    make(map[int]string),
+	}
+	for _, e := range entries {
+		idx.Add(e)
+	}
+	return idx
+}
+
+// WithHashIndex is a TL client option to set the hash index. Torrents
+// downloaded by the client are added to the index (failures to index are
+// logged, and do not fail the download).
+func WithHashIndex(idx *HashIndex) Option {
+	return func(cl *Client) {
+		cl.index = idx
+	}
+}
+
+// Add adds the entry to the index.
+func (idx *HashIndex) Add(e HashEntry) {
+	e.InfoHash = strings.ToLower(e.InfoHash)
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if prev, ok := idx.ids[e.ID]; ok {
+		delete(idx.hashes, prev)
+	}
+	idx.hashes[e.InfoHash] = e
+	idx.ids[e.ID] = e.InfoHash
+}
+
+// AddMetainfo parses the torrent metainfo and adds it to the index for the
+// id.
+func (idx *HashIndex) AddMetainfo(id int, buf []byte) (HashEntry, error) {
+	m, err := ParseMetainfo(buf)
+	if err != nil {
+		return HashEntry{}, err
+	}
+	e := HashEntry{
+		ID:       id,
+		InfoHash: m.InfoHash,
+		Name:     m.Name,
+		Size:     m.Length,
+	}
+	idx.Add(e)
+	return e, nil
+}
+
+// Lookup returns the entry for the info hash.
+func (idx *HashIndex) Lookup(hash string) (HashEntry, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	e, ok := idx.hashes[strings.ToLower(hash)]
+	return e, ok
+}
+
+// ByID returns the entry for the torrent id.
+func (idx *HashIndex) ByID(id int) (HashEntry, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	hash, ok := idx.ids[id]
+	if !ok {
+		return HashEntry{}, false
+	}
+	return idx.hashes[hash], true
+}
+
+// Entries returns the entries in the index.
+func (idx *HashIndex) Entries() []HashEntry {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	v := make([]HashEntry, 0, len(idx.hashes))
+	for _, e := range idx.hashes {
+		v = append(v, e)
+	}
+	return v
+}
+
+// Len returns the number of entries in the index.
+func (idx *HashIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.hashes)
+}
+
+// FindByInfoHash finds the torrent with the info hash in the client's hash
+// index, returning ErrNotFound when the hash is not indexed. The site does
+// not provide an info hash lookup, so only torrents previously downloaded or
+// indexed can be found.
+func (cl *Client) FindByInfoHash(ctx context.Context, hash string) (HashEntry, error) {
+	if cl.index == nil {
+		return HashEntry{}, errors.New("must supply hash index")
+	}
+	if err := ctx.Err(); err != nil {
+		return HashEntry{}, err
+	}
+	e, ok := cl.index.Lookup(hash)
+	if !ok {
+		return HashEntry{}, ErrNotFound
+	}
+	return e, nil
+}
@@ -0,0 +1,73 @@
+package tlapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// HashStore is the interface for persisting hash entries (see the store
+// subpackage).
+type HashStore interface {
+	PutHash(HashEntry) error
+	Hash(id int) (HashEntry, bool)
+}
+
+// Indexer builds a local info hash index by walking search results and
+// downloading the metainfo for selected torrents, persisting the id, info
+// hash, and size mappings to a hash store.
+type Indexer struct {
+	// Client is the client used to search and download.
+	Client *Client
+	// Store is the store the entries are persisted to.
+	Store HashStore
+	// Filter selects the torrents to index. When nil, all torrents are
+	// indexed.
+	Filter func(Torrent) bool
+	// Delay is the delay between downloads, in addition to any client
+	// budget.
+	Delay time.Duration
+	// OnIndexed, when not nil, is called for each indexed entry.
+	OnIndexed func(HashEntry)
+}
+
+// Index walks the search request's results, indexing selected torrents not
+// already in the store, and returns the number of torrents indexed.
+func (ix *Indexer) Index(ctx context.Context, req *SearchRequest) (int, error) {
+	n := 0
+	for req.Next(ctx, ix.Client) {
+		t := req.Cur()
+		if _, ok := ix.Store.Hash(t.ID); ok || ix.Filter != nil && !ix.Filter(t) {
+			continue
+		}
+		if n != 0 && ix.Delay != 0 {
+			select {
+			case <-ctx.Done():
+				return n, ctx.Err()
+			case <-time.After(ix.Delay):
+			}
+		}
+		buf, err := ix.Client.Torrent(ctx, t.ID)
+		if err != nil {
+			return n, err
+		}
+		m, err := ParseMetainfo(buf)
+		if err != nil {
+			return n, fmt.Errorf("torrent %d: %w", t.ID, err)
+		}
+		e := HashEntry{
+			ID:       t.ID,
+			InfoHash: m.InfoHash,
+			Name:     t.Name,
+			Size:     m.Length,
+		}
+		if err := ix.Store.PutHash(e); err != nil {
+			return n, err
+		}
+		n++
+		if ix.OnIndexed != nil {
+			ix.OnIndexed(e)
+		}
+	}
+	return n, req.Err()
+}
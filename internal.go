@@ -0,0 +1,56 @@
+package tlapi
+
+import (
+	"strings"
+)
+
+// Internal returns true when the torrent is an internal release: tagged
+// INTERNAL, or released by one of the groups (the site's internal groups).
+func (t Torrent) Internal(groups ...string) bool {
+	if t.HasTag(TagInternal) {
+		return true
+	}
+	if len(groups) == 0 {
+		return false
+	}
+	group := ParseRelease(t.Name).Group
+	for _, s := range groups {
+		if group != "" && strings.EqualFold(group, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithInternalOnly restricts the search to internal releases, adding the
+// INTERNAL tag facet filter, and skipping torrents returned by Next and All
+// that are not internal (see Torrent.Internal), with the groups treated as
+// internal groups. The internal check applies in addition to any filter set
+// with WithFilter.
+func (req *SearchRequest) WithInternalOnly(groups ...string) *SearchRequest {
+	r := req.clone()
+	facets := make(map[string]string, len(req.Facets)+1)
+	for k, v := range req.Facets {
+		facets[k] = v
+	}
+	tags := strings.Split(facets[FacetTags], ",")
+	if !containsFold(tags, TagInternal) {
+		if facets[FacetTags] == "" {
+			tags = nil
+		}
+		facets[FacetTags] = strings.Join(append(tags, TagInternal), ",")
+	}
+	r.Facets = facets
+	r.internalOnly, r.internal = true, groups
+	return r
+}
+
+// containsFold determines if v contains s, case-insensitively.
+func containsFold(v []string, s string) bool {
+	for _, t := range v {
+		if strings.EqualFold(t, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package tlapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// JSONLWriter writes torrents as JSON Lines (one torrent per line), for
+// streaming search or watch results to pipelines (such as jq). A JSON Lines
+// writer is safe for concurrent use.
+type JSONLWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	enc *json.Encoder
+}
+
+// NewJSONLWriter creates a JSON Lines writer. When w has a Flush method
+// (such as a bufio.Writer or http.ResponseWriter), it is flushed after each
+// torrent is written.
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &JSONLWriter{
+		w:   w,
+		enc: enc,
+	}
+}
+
+// Write writes the torrent as a line. Write can be passed directly to
+// SearchRequest.Stream.
+func (w *JSONLWriter) Write(t Torrent) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.enc.Encode(t); err != nil {
+		return err
+	}
+	switch f := w.w.(type) {
+	case interface{ Flush() error }:
+		return f.Flush()
+	case http.Flusher:
+		f.Flush()
+	}
+	return nil
+}
+
+// WriteAll writes the torrents.
+func (w *JSONLWriter) WriteAll(torrents []Torrent) error {
+	for _, t := range torrents {
+		if err := w.Write(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package tlapi
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LibraryItem is a locally owned media item.
+type LibraryItem struct {
+	Title   string `json:"title"`
+	Year    int    `json:"year,omitempty"`
+	Season  int    `json:"season,omitempty"`
+	Episode int    `json:"episode,omitempty"`
+	Path    string `json:"path,omitempty"`
+}
+
+// Library is an index of locally owned media, used to check search results
+// against content already owned. Items are matched by normalized title,
+// year, season, and episode. A library is safe for concurrent use.
+type Library struct {
+	mu    sync.RWMutex
+	items map[string][]LibraryItem
+}
+
+// NewLibrary creates a library containing the items.
+func NewLibrary(items ...LibraryItem) *Library {
+	l := &Library{
+		items: make(map[string][]LibraryItem),
+	}
+	for _, item := range items {
+		l.Add(item)
+	}
+	return l
+}
+
+// libraryExts are the media file extensions scanned.
+var libraryExts = map[string]bool{
+	".avi":  true,
+	".iso":  true,
+	".m2ts": true,
+	".m4v":  true,
+	".mkv":  true,
+	".mov":  true,
+	".mp4":  true,
+	".ts":   true,
+	".wmv":  true,
+}
+
+// Add adds the item to the library.
+func (l *Library) Add(item LibraryItem) {
+	key := libraryKey(item.Title, item.Season, item.Episode)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.items[key] = append(l.items[key], item)
+}
+
+// AddName adds the item parsed from the release or file name (for example,
+// "The Movie (2019)" or "Show.S01E02.1080p.WEB-DL-GROUP") to the library.
+func (l *Library) AddName(name, path string) bool {
+	r := ParseRelease(name)
+	if NormalizeTitle(r.Title) == "" {
+		return false
+	}
+	l.Add(LibraryItem{
+		Title:   r.Title,
+		Year:    r.Year,
+		Season:  r.Season,
+		Episode: r.Episode,
+		Path:    path,
+	})
+	return true
+}
+
+// Scan walks the directories, adding media files to the library. When a
+// file name does not include a title (for example, "S01E02.mkv"), the title
+// and year are taken from the nearest parent directory that does, skipping
+// season directories.
+func (l *Library) Scan(dirs ...string) error {
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			switch {
+			case err != nil:
+				return err
+			case d.IsDir() || !libraryExts[strings.ToLower(filepath.Ext(path))]:
+				return nil
+			}
+			name := strings.TrimSuffix(d.Name(), filepath.Ext(path))
+			r := ParseRelease(name)
+			for p := filepath.Dir(path); NormalizeTitle(r.Title) == "" || isSeasonDir(r.Title); p = filepath.Dir(p) {
+				if p == dir || p == filepath.Dir(p) {
+					return nil
+				}
+				parent := ParseRelease(filepath.Base(p))
+				r.Title, r.Year = parent.Title, parent.Year
+				if r.Season == 0 {
+					r.Season = parent.Season
+				}
+			}
+			l.Add(LibraryItem{
+				Title:   r.Title,
+				Year:    r.Year,
+				Season:  r.Season,
+				Episode: r.Episode,
+				Path:    path,
+			})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSeasonDir returns true when the title is a season directory name (for
+// example, "Season 01" or "Specials").
+func isSeasonDir(title string) bool {
+	s := NormalizeTitle(title)
+	if s == "specials" {
+		return true
+	}
+	if n := strings.TrimPrefix(s, "season "); n != s {
+		_, err := strconv.Atoi(n)
+		return err == nil
+	}
+	return false
+}
+
+// LoadExport adds the items from a JSON array of library items (for example,
+// converted from a Plex or Jellyfin library export) to the library.
+func (l *Library) LoadExport(r io.Reader) error {
+	var items []LibraryItem
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return err
+	}
+	for _, item := range items {
+		l.Add(item)
+	}
+	return nil
+}
+
+// Len returns the number of items in the library.
+func (l *Library) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	n := 0
+	for _, v := range l.items {
+		n += len(v)
+	}
+	return n
+}
+
+// Owned returns the library item matching the torrent's parsed release
+// name. Years are only compared when known for both. An episode is owned
+// when the episode or its whole season is owned.
+func (l *Library) Owned(t Torrent) (LibraryItem, bool) {
+	r := ParseRelease(t.Name)
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	keys := []string{libraryKey(r.Title, r.Season, r.Episode)}
+	if r.Episode != 0 {
+		keys = append(keys, libraryKey(r.Title, r.Season, 0))
+	}
+	for _, key := range keys {
+		for _, item := range l.items[key] {
+			if r.Year == 0 || item.Year == 0 || r.Year == item.Year {
+				return item, true
+			}
+		}
+	}
+	return LibraryItem{}, false
+}
+
+// Filter returns a filter func excluding owned torrents, suitable for use
+// with an Indexer or search iteration.
+func (l *Library) Filter() func(Torrent) bool {
+	return func(t Torrent) bool {
+		_, ok := l.Owned(t)
+		return !ok
+	}
+}
+
+// libraryKey returns the library key.
+func libraryKey(title string, season, episode int) string {
+	return NormalizeTitle(title) + ":s" + strconv.Itoa(season) + "e" + strconv.Itoa(episode)
+}
@@ -0,0 +1,128 @@
+package tlapi
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limiter is the interface for request rate limiters. A Budget is a
+// Limiter for a single process; a FileLimiter coordinates multiple
+// processes.
+type Limiter interface {
+	// Wait waits until a request is allowed, and records it.
+	Wait(context.Context) error
+}
+
+// WithLimiter is a TL client option to set the request limiter. Every
+// request made by the client waits for the limiter to allow it.
+func WithLimiter(l Limiter) Option {
+	return func(cl *Client) {
+		cl.limiter = l
+	}
+}
+
+// FileLimiter is a request limiter allowing limit requests per rolling
+// window, shared between processes through a locked state file, so that
+// multiple processes using the same account coordinate on a single request
+// rate. Requires file locking support (unix systems).
+type FileLimiter struct {
+	path   string
+	limit  int
+	window time.Duration
+}
+
+// NewFileLimiter creates a file limiter using the state file at the path,
+// allowing limit requests per rolling window.
+func NewFileLimiter(path string, limit int, window time.Duration) *FileLimiter {
+	return &FileLimiter{
+		path:   path,
+		limit:  limit,
+		window: window,
+	}
+}
+
+// Wait satisfies the Limiter interface.
+func (l *FileLimiter) Wait(ctx context.Context) error {
+	for {
+		next, err := l.take()
+		switch {
+		case err != nil:
+			return fmt.Errorf("file limiter %s: %w", l.path, err)
+		case next.IsZero():
+			return nil
+		}
+		t := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// take records a request when allowed, otherwise returning the time the
+// next request is allowed.
+func (l *FileLimiter) take() (time.Time, error) {
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		return time.Time{}, err
+	}
+	defer unlockFile(f)
+	times, err := readTimes(f)
+	if err != nil {
+		return time.Time{}, err
+	}
+	now := time.Now()
+	i := 0
+	for i < len(times) && now.Sub(times[i]) >= l.window {
+		i++
+	}
+	times = times[i:]
+	switch {
+	case l.limit <= 0:
+		return now.Add(l.window), nil
+	case len(times) >= l.limit:
+		return times[len(times)-l.limit].Add(l.window), nil
+	}
+	times = append(times, now)
+	var b strings.Builder
+	for _, t := range times {
+		b.WriteString(strconv.FormatInt(t.UnixNano(), 10) + "\n")
+	}
+	if err := f.Truncate(0); err != nil {
+		return time.Time{}, err
+	}
+	if _, err := f.WriteAt([]byte(b.String()), 0); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time{}, nil
+}
+
+// readTimes reads the request times from the state file.
+func readTimes(r io.Reader) ([]time.Time, error) {
+	var times []time.Time
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		i, err := strconv.ParseInt(line, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid state %q", line)
+		}
+		times = append(times, time.Unix(0, i))
+	}
+	return times, s.Err()
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package tlapi
+
+import (
+	"errors"
+	"os"
+)
+
+// lockFile exclusively locks the file. Not supported on this platform.
+func lockFile(*os.File) error {
+	return errors.New("file locking not supported")
+}
+
+// unlockFile unlocks the file.
+func unlockFile(*os.File) error {
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package tlapi
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile exclusively locks the file, waiting until the lock is available.
+func lockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
+
+// unlockFile unlocks the file.
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,289 @@
+package tlapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ErrLoginFailed is the login failed error.
+var ErrLoginFailed = errors.New("login failed")
+
+// LoginError is a login error, with the message shown by the site (if any).
+type LoginError struct {
+	Message string
+}
+
+// Error satisfies the error interface.
+func (err *LoginError) Error() string {
+	if err.Message != "" {
+		return "login failed: " + err.Message
+	}
+	return "login failed"
+}
+
+// Unwrap returns ErrLoginFailed.
+func (err *LoginError) Unwrap() error {
+	return ErrLoginFailed
+}
+
+// loginPath is the site's login path.
+const loginPath = "/user/account/login"
+
+// Login logs in to the site with the user name and password, capturing the
+// session cookies (PHPSESSID, tluid, and tlpass) into the client's jar,
+// creating the jar when the client has none. CAPTCHA challenges on the
+// login form are solved with the client's solver (see WithCaptchaSolver),
+// and two-factor authentication codes are generated or prompted for with
+// the client's TOTP secret or prompt (see WithTOTPSecret and
+// WithOTPPrompt). Returns a LoginError when the site rejects the login.
+func (cl *Client) Login(ctx context.Context, user, pass string) error {
+	if cl.Jar == nil {
+		jar, err := NewJar(cl.base)
+		if err != nil {
+			return fmt.Errorf("login: %w", err)
+		}
+		cl.Jar = jar
+		if cl.cl.Jar == nil {
+			cl.cl.Jar = jar
+		}
+	}
+	buf, u, err := cl.loginPage(ctx, "GET", loginPath, nil)
+	if err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	form := parseLoginForm(u, buf)
+	if form == nil {
+		return fmt.Errorf("login: no login form found")
+	}
+	form.values.Set(form.user, user)
+	form.values.Set(form.pass, pass)
+	// remove any existing login cookies, so that only cookies set by this
+	// login count as success, restoring them when the login fails
+	site, err := url.Parse(cl.url("/"))
+	if err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	prev := loginCookies(cl.Jar.Cookies(site))
+	setCookies(cl.Jar, site, []*http.Cookie{{Name: "tluid", MaxAge: -1}, {Name: "tlpass", MaxAge: -1}})
+	if err := cl.submitLogin(ctx, u, buf, form); err != nil {
+		if len(prev) != 0 && !cl.loggedIn() {
+			setCookies(cl.Jar, site, prev)
+		}
+		return err
+	}
+	cl.Resume()
+	return nil
+}
+
+// submitLogin submits the login form, and the two-factor authentication
+// form when required.
+func (cl *Client) submitLogin(ctx context.Context, u *url.URL, buf []byte, form *loginForm) error {
+	var err error
+	for i := 0; ; i++ {
+		if err := cl.solveLoginCaptcha(ctx, u, buf, form.values); err != nil {
+			return fmt.Errorf("login: %w", err)
+		}
+		if buf, u, err = cl.loginPage(ctx, "POST", form.action, form.values); err != nil {
+			return fmt.Errorf("login: %w", err)
+		}
+		if cl.loggedIn() {
+			return nil
+		}
+		// two-factor authentication form
+		next := parseLoginForm(u, buf)
+		if next == nil || next.otp == "" || i != 0 {
+			return &LoginError{Message: loginMessage(buf)}
+		}
+		if cl.otp == nil {
+			return fmt.Errorf("login: two-factor authentication required (see WithTOTPSecret)")
+		}
+		code, err := cl.otp(ctx)
+		if err != nil {
+			return fmt.Errorf("login: %w", err)
+		}
+		form = next
+		form.values.Set(form.otp, code)
+	}
+}
+
+// loginPage requests the login page, returning the response body and url.
+func (cl *Client) loginPage(ctx context.Context, method, urlstr string, values url.Values) ([]byte, *url.URL, error) {
+	var body io.Reader
+	if values != nil {
+		body = strings.NewReader(values.Encode())
+	}
+	req, err := http.NewRequest(method, cl.url(urlstr), body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if values != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	res, err := cl.send(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	buf, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, nil, newRequestError(req, err)
+	}
+	return buf, res.Request.URL, nil
+}
+
+// solveLoginCaptcha solves a CAPTCHA challenge in the login page, adding
+// the response to the form values.
+func (cl *Client) solveLoginCaptcha(ctx context.Context, u *url.URL, buf []byte, values url.Values) error {
+	c := detectCaptcha(u, buf)
+	if c == nil {
+		return nil
+	}
+	if cl.captcha == nil {
+		return &CaptchaError{Captcha: c}
+	}
+	token, err := cl.captcha.SolveCaptcha(ctx, c)
+	if err != nil {
+		return fmt.Errorf("unable to solve %s: %w", c.Kind, err)
+	}
+	values.Set(c.field(), token)
+	return nil
+}
+
+// loggedIn determines if the client's jar has the site's login cookies.
+func (cl *Client) loggedIn() bool {
+	u, err := url.Parse(cl.url("/"))
+	if err != nil {
+		return false
+	}
+	var uid, pass bool
+	for _, c := range cl.Jar.Cookies(u) {
+		switch c.Name {
+		case "tluid":
+			uid = c.Value != ""
+		case "tlpass":
+			pass = c.Value != ""
+		}
+	}
+	return uid && pass
+}
+
+// loginCookies returns the site's login cookies (tluid and tlpass) in the
+// cookies.
+func loginCookies(cookies []*http.Cookie) []*http.Cookie {
+	var v []*http.Cookie
+	for _, c := range cookies {
+		if c.Name == "tluid" || c.Name == "tlpass" {
+			v = append(v, c)
+		}
+	}
+	return v
+}
+
+// loginForm is a parsed login or two-factor authentication form.
+type loginForm struct {
+	action string
+	values url.Values
+	user   string
+	pass   string
+	otp    string
+}
+
+// parseLoginForm parses the first form with a password or one-time code
+// input from the page, keeping its hidden input values.
+func parseLoginForm(u *url.URL, buf []byte) *loginForm {
+	doc, err := html.Parse(bytes.NewReader(buf))
+	if err != nil {
+		return nil
+	}
+	var form *loginForm
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if form != nil {
+			return
+		}
+		if n.Type == html.ElementNode && n.DataAtom == atom.Form {
+			if f := newLoginForm(u, n); f.pass != "" || f.otp != "" {
+				form = f
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return form
+}
+
+// newLoginForm creates a login form from the form element.
+func newLoginForm(u *url.URL, n *html.Node) *loginForm {
+	f := &loginForm{
+		action: u.String(),
+		values: make(url.Values),
+	}
+	if action := attr(n, "action"); action != "" {
+		if a, err := u.Parse(action); err == nil {
+			f.action = a.String()
+		}
+	}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Input {
+			name, typ := attr(n, "name"), strings.ToLower(attr(n, "type"))
+			lower := strings.ToLower(name)
+			switch {
+			case name == "":
+			case typ == "hidden":
+				f.values.Set(name, attr(n, "value"))
+			case typ == "password" && f.pass == "":
+				f.pass = name
+			case strings.Contains(lower, "otp") || strings.Contains(lower, "2fa") || strings.Contains(lower, "code") || attr(n, "autocomplete") == "one-time-code":
+				f.otp = name
+			case (typ == "" || typ == "text" || typ == "email") && f.user == "":
+				f.user = name
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	if f.user == "" {
+		f.user = "username"
+	}
+	return f
+}
+
+// loginMessage returns the error message shown on a login page, from the
+// first element with an "error" or "alert" class.
+func loginMessage(buf []byte) string {
+	doc, err := html.Parse(bytes.NewReader(buf))
+	if err != nil {
+		return ""
+	}
+	var msg string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if msg != "" {
+			return
+		}
+		if n.Type == html.ElementNode && (hasClass(n, "error") || hasClass(n, "alert") || hasClass(n, "login-error")) {
+			msg = text(n)
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return msg
+}
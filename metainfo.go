@@ -0,0 +1,223 @@
+package tlapi
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"time"
+)
+
+// Metainfo is parsed torrent (.torrent) metainfo.
+type Metainfo struct {
+	InfoHash     string         `json:"infoHash"`
+	Announce     string         `json:"announce,omitempty"`
+	AnnounceList [][]string     `json:"announceList,omitempty"`
+	Comment      string         `json:"comment,omitempty"`
+	CreatedBy    string         `json:"createdBy,omitempty"`
+	CreationDate time.Time      `json:"creationDate,omitempty"`
+	Name         string         `json:"name"`
+	PieceLength  int64          `json:"pieceLength"`
+	Pieces       int            `json:"pieces"`
+	Private      bool           `json:"private,omitempty"`
+	Length       int64          `json:"length"`
+	Files        []MetainfoFile `json:"files,omitempty"`
+}
+
+// MetainfoFile is a file in multi-file torrent metainfo.
+type MetainfoFile struct {
+	Path   string `json:"path"`
+	Length int64  `json:"length"`
+}
+
+// ParseMetainfo parses torrent metainfo.
+func ParseMetainfo(buf []byte) (*Metainfo, error) {
+	d := &bdecoder{buf: buf}
+	v, err := d.decode()
+	if err != nil {
+		return nil, fmt.Errorf("invalid metainfo: %w", err)
+	}
+	root, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid metainfo: not a dictionary")
+	}
+	if d.infoStart < 0 {
+		return nil, errors.New("invalid metainfo: missing info dictionary")
+	}
+	info, ok := root["info"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid metainfo: invalid info dictionary")
+	}
+	sum := sha1.Sum(buf[d.infoStart:d.infoEnd])
+	m := &Metainfo{
+		InfoHash:    hex.EncodeToString(sum[:]),
+		Announce:    bstring(root["announce"]),
+		Comment:     bstring(root["comment"]),
+		CreatedBy:   bstring(root["created by"]),
+		Name:        bstring(info["name"]),
+		PieceLength: bint(info["piece length"]),
+		Pieces:      len(bstring(info["pieces"])) / sha1.Size,
+		Private:     bint(info["private"]) == 1,
+	}
+	if i := bint(root["creation date"]); i != 0 {
+		m.CreationDate = time.Unix(i, 0)
+	}
+	if tiers, ok := root["announce-list"].([]interface{}); ok {
+		for _, tier := range tiers {
+			var v []string
+			if urls, ok := tier.([]interface{}); ok {
+				for _, u := range urls {
+					v = append(v, bstring(u))
+				}
+			}
+			m.AnnounceList = append(m.AnnounceList, v)
+		}
+	}
+	if files, ok := info["files"].([]interface{}); ok {
+		for _, f := range files {
+			file, ok := f.(map[string]interface{})
+			if !ok {
+				return nil, errors.New("invalid metainfo: invalid file")
+			}
+			var parts []string
+			if p, ok := file["path"].([]interface{}); ok {
+				for _, s := range p {
+					parts = append(parts, bstring(s))
+				}
+			}
+			length := bint(file["length"])
+			m.Files = append(m.Files, MetainfoFile{
+				Path:   path.Join(parts...),
+				Length: length,
+			})
+			m.Length += length
+		}
+	} else {
+		m.Length = bint(info["length"])
+	}
+	return m, nil
+}
+
+// InfoHash returns the hex encoded info hash for the torrent metainfo.
+func InfoHash(buf []byte) (string, error) {
+	m, err := ParseMetainfo(buf)
+	if err != nil {
+		return "", err
+	}
+	return m.InfoHash, nil
+}
+
+// bdecoder is a bencode decoder.
+type bdecoder struct {
+	buf   []byte
+	i     int
+	depth int
+	// infoStart and infoEnd are the offsets of the root info dictionary.
+	infoStart, infoEnd int
+}
+
+// decode decodes the next bencoded value. Strings are decoded as string,
+// integers as int64, lists as []interface{}, and dictionaries as
+// map[string]interface{}.
+func (d *bdecoder) decode() (interface{}, error) {
+	if d.depth == 0 {
+		d.infoStart = -1
+	}
+	if d.i >= len(d.buf) {
+		return nil, errors.New("unexpected end of data")
+	}
+	switch c := d.buf[d.i]; {
+	case c == 'i':
+		end := d.index('e', d.i+1)
+		if end < 0 {
+			return nil, errors.New("unterminated integer")
+		}
+		i, err := strconv.ParseInt(string(d.buf[d.i+1:end]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer at %d", d.i)
+		}
+		d.i = end + 1
+		return i, nil
+	case c == 'l':
+		d.i++
+		d.depth++
+		var v []interface{}
+		for d.i < len(d.buf) && d.buf[d.i] != 'e' {
+			x, err := d.decode()
+			if err != nil {
+				return nil, err
+			}
+			v = append(v, x)
+		}
+		return v, d.end()
+	case c == 'd':
+		d.i++
+		d.depth++
+		m := make(map[string]interface{})
+		for d.i < len(d.buf) && d.buf[d.i] != 'e' {
+			k, err := d.decode()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid dictionary key at %d", d.i)
+			}
+			start := d.i
+			if m[key], err = d.decode(); err != nil {
+				return nil, err
+			}
+			if d.depth == 1 && key == "info" {
+				d.infoStart, d.infoEnd = start, d.i
+			}
+		}
+		return m, d.end()
+	case '0' <= c && c <= '9':
+		colon := d.index(':', d.i)
+		if colon < 0 {
+			return nil, errors.New("unterminated string length")
+		}
+		n, err := strconv.Atoi(string(d.buf[d.i:colon]))
+		if err != nil || n < 0 || colon+1+n > len(d.buf) {
+			return nil, fmt.Errorf("invalid string length at %d", d.i)
+		}
+		d.i = colon + 1 + n
+		return string(d.buf[colon+1 : d.i]), nil
+	default:
+		return nil, fmt.Errorf("invalid token %q at %d", c, d.i)
+	}
+}
+
+// end consumes the end of a list or dictionary.
+func (d *bdecoder) end() error {
+	if d.i >= len(d.buf) {
+		return errors.New("unterminated list or dictionary")
+	}
+	d.i++
+	d.depth--
+	return nil
+}
+
+// index returns the index of c in the buffer at or after i.
+func (d *bdecoder) index(c byte, i int) int {
+	for ; i < len(d.buf); i++ {
+		if d.buf[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// bstring returns the value as a string.
+func bstring(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// bint returns the value as an int64.
+func bint(v interface{}) int64 {
+	i, _ := v.(int64)
+	return i
+}
@@ -0,0 +1,93 @@
+package tlapi
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// NFO retrieves the torrent's nfo, converted to UTF-8 (see DecodeNFO).
+func (cl *Client) NFO(ctx context.Context, id int, stripANSI bool) (string, error) {
+	buf, err := cl.download(ctx, fmt.Sprintf("/torrents/torrent/nfo/%d", id))
+	if err != nil {
+		return "", fmt.Errorf("nfo %d: %w", id, err)
+	}
+	return DecodeNFO(buf, stripANSI), nil
+}
+
+// DecodeNFO converts a nfo to UTF-8. Nfos that are not valid UTF-8 are
+// detected as either CP437 (the DOS code page used for nfo art) or latin-1
+// (ISO 8859-1). When stripANSI is true, ANSI escape sequences and any
+// trailing SAUCE record are removed.
+func DecodeNFO(buf []byte, stripANSI bool) string {
+	var s string
+	switch {
+	case utf8.Valid(buf):
+		s = strings.TrimPrefix(string(buf), "\ufeff")
+	case isCP437(buf):
+		s = decodeCharmap(buf, &cp437)
+	default:
+		s = decodeCharmap(buf, nil)
+	}
+	if stripANSI {
+		if i := strings.IndexByte(s, 0x1a); i != -1 {
+			s = s[:i]
+		}
+		s = ansiRE.ReplaceAllString(s, "")
+	}
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
+
+// ansiRE matches ANSI escape sequences.
+var ansiRE = regexp.MustCompile(`\x1b(?:\[[0-?]*[ -/]*[@-~]|[@-Z\\-_])`)
+
+// isCP437 determines if the high bytes in buf are likely CP437, as used for
+// nfo art (box drawing and block characters), rather than latin-1 text.
+func isCP437(buf []byte) bool {
+	high, art := 0, 0
+	for _, c := range buf {
+		switch {
+		case c >= 0xb0 && c <= 0xdf:
+			art++
+			fallthrough
+		case c >= 0x80:
+			high++
+		}
+	}
+	return high != 0 && art*2 >= high
+}
+
+// decodeCharmap decodes buf using the high byte charmap, or latin-1 when
+// nil.
+func decodeCharmap(buf []byte, charmap *[128]rune) string {
+	var b strings.Builder
+	b.Grow(len(buf))
+	for _, c := range buf {
+		switch {
+		case c < 0x80:
+			b.WriteByte(c)
+		case charmap != nil:
+			b.WriteRune(charmap[c-0x80])
+		default:
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
+
+// cp437 is the CP437 charmap for bytes 0x80-0xff.
+var cp437 = func() [128]rune {
+	var v [128]rune
+	copy(v[:], []rune(""+
+		"ÇüéâäàåçêëèïîìÄÅ"+
+		"ÉæÆôöòûùÿÖÜ¢£¥₧ƒ"+
+		"áíóúñÑªº¿⌐¬½¼¡«»"+
+		"░▒▓│┤╡╢╖╕╣║╗╝╜╛┐"+
+		"└┴┬├─┼╞╟╚╔╩╦╠═╬╧"+
+		"╨╤╥╙╘╒╓╫╪┘┌█▄▌▐▀"+
+		"αßΓπΣσµτΦΘΩδ∞φε∩"+
+		"≡±≥≤⌠⌡÷≈°∙·√ⁿ²■ "))
+	return v
+}()
@@ -0,0 +1,62 @@
+package tlapi
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// nukeTags are the lower case tags marking nuked or retired torrents.
+var nukeTags = []string{"nuked", "nuke", "retired"}
+
+// nukeStatus returns the nuke status and reason from the tags. The reason
+// follows the tag after a ':' (for example, "NUKED: bad.aspect.ratio").
+func nukeStatus(tags []string) (bool, string) {
+	for _, tag := range tags {
+		name, reason, _ := strings.Cut(tag, ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		for _, s := range nukeTags {
+			if name == s {
+				return true, strings.TrimSpace(reason)
+			}
+		}
+	}
+	return false, ""
+}
+
+// isNuke determines if the html element is a nuke or retired marker.
+func isNuke(n *html.Node) bool {
+	for _, class := range nukeTags {
+		if hasClass(n, class) {
+			return true
+		}
+	}
+	return false
+}
+
+// nukeReason returns the nuke reason from a nuke marker element, from its
+// title attribute or its text following a "Nuked:" (or similar) label.
+func nukeReason(n *html.Node) string {
+	if s := strings.TrimSpace(attr(n, "title")); s != "" {
+		return trimNukeLabel(s)
+	}
+	return trimNukeLabel(text(n))
+}
+
+// trimNukeLabel trims a leading nuke label from s.
+func trimNukeLabel(s string) string {
+	if label, reason, ok := strings.Cut(s, ":"); ok {
+		label = strings.ToLower(strings.TrimSpace(label))
+		for _, t := range nukeTags {
+			if label == t {
+				return strings.TrimSpace(reason)
+			}
+		}
+	}
+	for _, t := range nukeTags {
+		if strings.EqualFold(strings.TrimSpace(s), t) {
+			return ""
+		}
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,50 @@
+package tlapi
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WithTOTPSecret is a TL client option to set the base32 TOTP secret used to
+// generate one-time codes when logging in to accounts with two-factor
+// authentication enabled.
+func WithTOTPSecret(secret string) Option {
+	return func(cl *Client) {
+		cl.otp = func(context.Context) (string, error) {
+			return TOTP(secret, time.Now())
+		}
+	}
+}
+
+// WithOTPPrompt is a TL client option to set a func that prompts for the
+// one-time code when logging in to accounts with two-factor authentication
+// enabled.
+func WithOTPPrompt(f func(ctx context.Context) (string, error)) Option {
+	return func(cl *Client) {
+		cl.otp = f
+	}
+}
+
+// TOTP generates the 6 digit RFC 6238 time-based one-time code (SHA-1, 30
+// second step) for the base32 secret at the time.
+func TOTP(secret string, t time.Time) (string, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return "", fmt.Errorf("invalid totp secret: %w", err)
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(t.Unix()/30))
+	h := hmac.New(sha1.New, key)
+	h.Write(buf[:])
+	sum := h.Sum(nil)
+	i := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[i:i+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", code%1000000), nil
+}
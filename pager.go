@@ -0,0 +1,170 @@
+package tlapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// PageFunc retrieves a page of items, returning true when more pages
+// follow.
+type PageFunc[T any] func(ctx context.Context, page int) ([]T, bool, error)
+
+// Pager is a cursor over paginated results, retrieving pages as needed.
+// A pager is safe for concurrent use.
+type Pager[T any] struct {
+	// Timeout is the timeout for each page fetch. When the timeout expires,
+	// Next returns false and Err returns an error wrapping
+	// context.DeadlineExceeded. Must be set before calling Next.
+	Timeout time.Duration
+	// Retries is the number of times a page fetch failing with a transient
+	// error (see IsTransient) is retried, waiting Backoff (1 second when 0)
+	// before the first retry and doubling the wait after each. Must be set
+	// before calling Next.
+	Retries int
+	Backoff time.Duration
+
+	fetch PageFunc[T]
+	page  int
+	d     time.Duration
+
+	items []T
+	more  bool
+	i     int
+	p     int
+	err   error
+	mu    sync.Mutex
+}
+
+// NewPager creates a pager starting at the page, waiting the delay between
+// successive page fetches.
+func NewPager[T any](page int, delay time.Duration, fetch PageFunc[T]) *Pager[T] {
+	if page == 0 {
+		page = 1
+	}
+	return &Pager[T]{
+		fetch: fetch,
+		page:  page,
+		d:     delay,
+		i:     -1,
+		p:     -1,
+	}
+}
+
+// Next returns true if there are results available.
+//
+// Example:
+//
+//	for p.Next(ctx) {
+//		item := p.Cur()
+//		/* ... */
+//	}
+//	if err := p.Err(); err != nil {
+//		/* ... */
+//	}
+func (p *Pager[T]) Next(ctx context.Context) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case p.err != nil:
+		return false
+	case p.p != -1:
+		switch {
+		case p.i < len(p.items)-1:
+			p.i++
+			return true
+		case !p.more:
+			return false
+		}
+	}
+	p.p, p.i = p.p+1, 0
+	if p.d != 0 && p.p != 0 {
+		select {
+		case <-ctx.Done():
+			p.err = ctx.Err()
+			return false
+		case <-time.After(p.d):
+		}
+	}
+	backoff := p.Backoff
+	if backoff == 0 {
+		backoff = time.Second
+	}
+	for retry := 0; ; retry++ {
+		p.items, p.more, p.err = p.fetchPage(ctx)
+		if p.err == nil || retry >= p.Retries || ctx.Err() != nil || !IsTransient(p.err) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			p.err = ctx.Err()
+			return false
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return p.err == nil && p.i < len(p.items)
+}
+
+// fetchPage fetches the current page, applying the timeout.
+func (p *Pager[T]) fetchPage(ctx context.Context) ([]T, bool, error) {
+	if p.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	return p.fetch(ctx, p.page+p.p)
+}
+
+// IsTransient determines if the error is likely transient, such as a
+// timeout, a connection error, or a server error (5xx) or rate limited (429)
+// http status.
+func IsTransient(err error) bool {
+	var serr *StatusError
+	var nerr net.Error
+	switch {
+	case errors.Is(err, ErrSessionExpired), errors.Is(err, ErrPaused), errors.Is(err, context.Canceled):
+		return false
+	case errors.As(err, &serr):
+		return serr.StatusCode >= 500 || serr.StatusCode == http.StatusTooManyRequests
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, io.ErrUnexpectedEOF), errors.As(err, &nerr):
+		return true
+	}
+	return false
+}
+
+// Cur returns the cursor's current item. Returns the same value until Next
+// is called. Panics if called prior to Next.
+func (p *Pager[T]) Cur() T {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.items[p.i]
+}
+
+// Err returns the last error.
+func (p *Pager[T]) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// Page returns the current page.
+func (p *Pager[T]) Page() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.page + p.p
+}
+
+// All returns all remaining results. When an error occurs, the results
+// collected before the error are returned along with it.
+func (p *Pager[T]) All(ctx context.Context) ([]T, error) {
+	var v []T
+	for p.Next(ctx) {
+		v = append(v, p.Cur())
+	}
+	return v, p.Err()
+}
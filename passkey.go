@@ -0,0 +1,117 @@
+package tlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// passkeyRE matches a passkey (32 hex digits).
+var passkeyRE = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+
+// Passkey returns the passkey embedded in the metainfo's announce url,
+// either as a "passkey" query parameter or as a path segment (for example,
+// "https://tracker.example.org/a/<passkey>/announce"). Returns an empty
+// string when the announce url has no passkey.
+func (m *Metainfo) Passkey() string {
+	urls := []string{m.Announce}
+	for _, tier := range m.AnnounceList {
+		urls = append(urls, tier...)
+	}
+	for _, s := range urls {
+		if passkey := announcePasskey(s); passkey != "" {
+			return passkey
+		}
+	}
+	return ""
+}
+
+// announcePasskey returns the passkey in the announce url.
+func announcePasskey(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return ""
+	}
+	for _, key := range []string{"passkey", "torrent_pass"} {
+		if v := u.Query().Get(key); v != "" {
+			return v
+		}
+	}
+	for _, part := range strings.Split(u.Path, "/") {
+		if passkeyRE.MatchString(part) {
+			return part
+		}
+	}
+	return ""
+}
+
+// Passkey returns the account's passkey, as set with WithPasskey, or
+// extracted from the announce url of a downloaded torrent (the latest
+// upload) and cached on the client.
+func (cl *Client) Passkey(ctx context.Context) (string, error) {
+	_, passkey, err := cl.tracker(ctx, false)
+	return passkey, err
+}
+
+// Announce returns the account's announce url, extracted from a downloaded
+// torrent (the latest upload) and cached on the client.
+func (cl *Client) Announce(ctx context.Context) (string, error) {
+	announce, _, err := cl.tracker(ctx, true)
+	return announce, err
+}
+
+// tracker returns the cached announce url and passkey, downloading a
+// torrent to extract them when the passkey (or the announce url, when
+// needed) is not known. The lock is not held while downloading, so that
+// DownloadURL is not blocked.
+func (cl *Client) tracker(ctx context.Context, announce bool) (string, string, error) {
+	cl.passkeyMu.Lock()
+	if cl.passkey != "" && (!announce || cl.announce != "") {
+		defer cl.passkeyMu.Unlock()
+		return cl.announce, cl.passkey, nil
+	}
+	cl.passkeyMu.Unlock()
+	announceURL, passkey, err := cl.extractTracker(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	cl.passkeyMu.Lock()
+	defer cl.passkeyMu.Unlock()
+	cl.announce = announceURL
+	if cl.passkey == "" {
+		cl.passkey = passkey
+	}
+	return cl.announce, cl.passkey, nil
+}
+
+// extractTracker downloads the latest upload's torrent, returning the
+// announce url and passkey from its metainfo.
+func (cl *Client) extractTracker(ctx context.Context) (string, string, error) {
+	res, err := Search().Do(ctx, cl)
+	if err != nil {
+		return "", "", fmt.Errorf("passkey: %w", err)
+	}
+	if len(res.TorrentList) == 0 {
+		return "", "", errors.New("passkey: no torrents to extract passkey from")
+	}
+	buf, err := cl.Torrent(ctx, res.TorrentList[0].ID)
+	if err != nil {
+		return "", "", fmt.Errorf("passkey: %w", err)
+	}
+	m, err := ParseMetainfo(buf)
+	if err != nil {
+		return "", "", fmt.Errorf("passkey: torrent %d: %w", res.TorrentList[0].ID, err)
+	}
+	passkey := m.Passkey()
+	if passkey == "" {
+		return "", "", fmt.Errorf("passkey: torrent %d: no passkey in announce url", res.TorrentList[0].ID)
+	}
+	announce := m.Announce
+	if announce == "" && len(m.AnnounceList) != 0 && len(m.AnnounceList[0]) != 0 {
+		announce = m.AnnounceList[0][0]
+	}
+	return announce, passkey, nil
+}
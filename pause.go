@@ -0,0 +1,83 @@
+package tlapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrPaused is the paused error, returned for requests made while the client
+// is paused after repeated blocked responses.
+var ErrPaused = errors.New("client paused after repeated blocked responses")
+
+// WithBlockPause is a TL client option to pause the client after n
+// consecutive blocked responses (403s or Cloudflare challenges), so that
+// polling does not keep burning requests against a blocked session. While
+// paused, requests fail immediately with ErrPaused. When not nil, notify is
+// called with the last blocked response error when the client pauses.
+//
+// The client resumes when its cookies are refreshed (with SetCookies, or a
+// successful OnCookieExpired or WithFlareSolverr refresh), or when Resume is
+// called. When a cookie refresh func is set, each request made while paused
+// first attempts a refresh, failing with ErrPaused when the refresh fails.
+func WithBlockPause(n int, notify func(error)) Option {
+	return func(cl *Client) {
+		cl.pauseAfter, cl.onPause = n, notify
+	}
+}
+
+// Paused returns true when the client is paused after repeated blocked
+// responses.
+func (cl *Client) Paused() bool {
+	cl.pauseMu.Lock()
+	defer cl.pauseMu.Unlock()
+	return cl.paused
+}
+
+// Resume resumes a paused client.
+func (cl *Client) Resume() {
+	cl.pauseMu.Lock()
+	defer cl.pauseMu.Unlock()
+	cl.paused, cl.blocks = false, 0
+}
+
+// checkPaused returns ErrPaused when the client is paused.
+func (cl *Client) checkPaused() error {
+	if cl.pauseAfter <= 0 {
+		return nil
+	}
+	if cl.Paused() {
+		return ErrPaused
+	}
+	return nil
+}
+
+// trackBlocked tracks consecutive blocked responses, pausing the client
+// after too many.
+func (cl *Client) trackBlocked(res *http.Response) {
+	if cl.pauseAfter <= 0 {
+		return
+	}
+	cl.pauseMu.Lock()
+	if !isBlocked(res) {
+		cl.blocks = 0
+		cl.pauseMu.Unlock()
+		return
+	}
+	cl.blocks++
+	pause := !cl.paused && cl.blocks >= cl.pauseAfter
+	if pause {
+		cl.paused = true
+	}
+	cl.pauseMu.Unlock()
+	if pause && cl.onPause != nil {
+		cl.onPause(fmt.Errorf("%d consecutive blocked responses (http status %d): %w", cl.pauseAfter, res.StatusCode, ErrPaused))
+	}
+}
+
+// isBlocked determines if the response is a block (403) or Cloudflare
+// challenge.
+func isBlocked(res *http.Response) bool {
+	return res.StatusCode == http.StatusForbidden ||
+		res.StatusCode == http.StatusServiceUnavailable && res.Header.Get("Cf-Mitigated") == "challenge"
+}
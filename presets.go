@@ -0,0 +1,55 @@
+package tlapi
+
+// PresetRemux4K returns a search request for 4K remux movies, newest first.
+func PresetRemux4K() *SearchRequest {
+	return Search().
+		WithCategories(CategoryMovies4k).
+		WithFacet(FacetTags, TagRemux).
+		WithOrderBy(OrderByAdded).
+		WithOrder(OrderDesc)
+}
+
+// PresetFreeleechRecent returns a search request for freeleech torrents added
+// in the last 24 hours, newest first.
+func PresetFreeleechRecent() *SearchRequest {
+	return Search().
+		WithFacets(
+			FacetTags, TagFreeleech,
+			FacetAdded, RangeLast24Hours,
+		).
+		WithOrderBy(OrderByAdded).
+		WithOrder(OrderDesc)
+}
+
+// PresetTVDaily returns a search request for TV episodes added in the last 24
+// hours, newest first.
+func PresetTVDaily() *SearchRequest {
+	return Search().
+		WithCategories(CategoryTVEpisodes, CategoryTVEpisodesHD, CategoryForeignTVSeries).
+		WithFacets(FacetAdded, RangeLast24Hours).
+		WithOrderBy(OrderByAdded).
+		WithOrder(OrderDesc)
+}
+
+// PresetPopularMovies returns a search request for HD movies added in the
+// last week with more than 200 seeders, most seeded first.
+func PresetPopularMovies() *SearchRequest {
+	return Search().
+		WithCategories(CategoryMoviesBluRayRip, CategoryMoviesWebRip, CategoryMoviesHDRip, CategoryMovies4k).
+		WithFacets(
+			FacetAdded, RangeLastWeek,
+			FacetSeeders, Seeders200Plus,
+		).
+		WithOrderBy(OrderBySeeders).
+		WithOrder(OrderDesc)
+}
+
+// PresetSeasonPacks returns a search request for TV boxsets and season packs
+// added in the last 2 weeks, newest first.
+func PresetSeasonPacks() *SearchRequest {
+	return Search().
+		WithCategories(CategoryTVBoxsets).
+		WithFacets(FacetAdded, RangeLast2Weeks).
+		WithOrderBy(OrderByAdded).
+		WithOrder(OrderDesc)
+}
@@ -0,0 +1,89 @@
+package tlapi
+
+import (
+	"strings"
+)
+
+// Decision is an auto-grab decision.
+type Decision string
+
+// Decision values.
+const (
+	DecisionSkip    Decision = "skip"
+	DecisionGrab    Decision = "grab"
+	DecisionUpgrade Decision = "upgrade"
+)
+
+// ProfileQuality is an allowed quality in a profile. Empty fields match any
+// value.
+type ProfileQuality struct {
+	Resolution string `json:"resolution,omitempty"`
+	Source     string `json:"source,omitempty"`
+	// Remux requires the release to be a remux.
+	Remux bool `json:"remux,omitempty"`
+	// MinSize and MaxSize are the size limits for the quality. A zero limit
+	// is unlimited.
+	MinSize int64 `json:"minSize,omitempty"`
+	MaxSize int64 `json:"maxSize,omitempty"`
+}
+
+// Match returns true when the release and size match the quality.
+func (q ProfileQuality) Match(r Release, size int64) bool {
+	return (q.Resolution == "" || strings.EqualFold(q.Resolution, r.Resolution)) &&
+		(q.Source == "" || strings.EqualFold(q.Source, r.Source)) &&
+		(!q.Remux || r.Remux) &&
+		(q.MinSize == 0 || size >= q.MinSize) &&
+		(q.MaxSize == 0 || size <= q.MaxSize)
+}
+
+// Profile is a quality profile for auto-grab decisions, similar to Sonarr
+// and Radarr quality profiles.
+type Profile struct {
+	// Qualities are the allowed qualities, ordered from most to least
+	// preferred.
+	Qualities []ProfileQuality `json:"qualities"`
+	// Upgrades enables upgrading existing releases.
+	Upgrades bool `json:"upgrades,omitempty"`
+	// Cutoff is the index of the quality in Qualities at or above which
+	// existing releases are no longer upgraded, other than for propers.
+	Cutoff int `json:"cutoff,omitempty"`
+}
+
+// Quality returns the index of the first quality in the profile matching
+// the torrent, or -1 when the torrent is not allowed.
+func (p Profile) Quality(t Torrent) int {
+	r := ParseRelease(t.Name)
+	for i, q := range p.Qualities {
+		if q.Match(r, t.Size) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Decide decides whether to grab the candidate torrent, given the existing
+// torrent (a zero Torrent when there is none).
+func (p Profile) Decide(existing, candidate Torrent) Decision {
+	cq := p.Quality(candidate)
+	switch {
+	case cq == -1:
+		return DecisionSkip
+	case existing.ID == 0 && existing.Name == "":
+		return DecisionGrab
+	case !p.Upgrades:
+		return DecisionSkip
+	}
+	eq := p.Quality(existing)
+	switch {
+	case eq == -1:
+		// existing is not allowed by the profile
+		return DecisionUpgrade
+	case cq == eq:
+		if ParseRelease(candidate.Name).Proper && !ParseRelease(existing.Name).Proper {
+			return DecisionUpgrade
+		}
+	case cq < eq && eq > p.Cutoff:
+		return DecisionUpgrade
+	}
+	return DecisionSkip
+}
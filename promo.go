@@ -0,0 +1,147 @@
+package tlapi
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// PromoType is a promotion type.
+type PromoType int
+
+// Promotion types.
+const (
+	PromoNone PromoType = iota
+	PromoFreeleech
+	PromoHalfLeech
+	PromoNeutralLeech
+	PromoSitewideFreeleech
+)
+
+// String satisfies the fmt.Stringer interface.
+func (typ PromoType) String() string {
+	switch typ {
+	case PromoNone:
+		return "none"
+	case PromoFreeleech:
+		return "freeleech"
+	case PromoHalfLeech:
+		return "halfleech"
+	case PromoNeutralLeech:
+		return "neutralleech"
+	case PromoSitewideFreeleech:
+		return "sitewide freeleech"
+	}
+	return "PromoType(" + strconv.Itoa(int(typ)) + ")"
+}
+
+// Promo is a torrent promotion.
+type Promo struct {
+	Type PromoType `json:"type"`
+	// Expires is when the promotion expires, when known.
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// DownloadFactor returns the fraction of the torrent's size counted as
+// downloaded.
+func (p Promo) DownloadFactor() float64 {
+	switch p.Type {
+	case PromoFreeleech, PromoNeutralLeech, PromoSitewideFreeleech:
+		return 0
+	case PromoHalfLeech:
+		return 0.5
+	}
+	return 1
+}
+
+// UploadFactor returns the fraction of the uploaded amount counted as
+// uploaded.
+func (p Promo) UploadFactor() float64 {
+	if p.Type == PromoNeutralLeech {
+		return 0
+	}
+	return 1
+}
+
+// Free returns true when downloads are not counted against the account's
+// ratio.
+func (p Promo) Free() bool {
+	return p.DownloadFactor() == 0
+}
+
+// Active returns true when the promotion is in effect at t.
+func (p Promo) Active(t time.Time) bool {
+	return p.Type != PromoNone && (p.Expires.IsZero() || t.Before(p.Expires))
+}
+
+// promoTags map lower case tags to their promotion types.
+var promoTags = map[string]PromoType{
+	"freeleech":    PromoFreeleech,
+	"halfleech":    PromoHalfLeech,
+	"neutralleech": PromoNeutralLeech,
+	"neutral":      PromoNeutralLeech,
+}
+
+// Promo returns the torrent's promotion, from its tags. Expiry is not
+// available from search results (see Details).
+func (t Torrent) Promo() Promo {
+	for _, tag := range t.Tags {
+		if typ, ok := promoTags[strings.ToLower(tag)]; ok {
+			return Promo{Type: typ}
+		}
+	}
+	return Promo{}
+}
+
+// parsePromo parses a promotion from a details page element with a
+// promotion class (such as "freeleech" or "sitewide"), reading the expiry
+// from a data-expires attribute (unix time or RFC3339) or a nested time
+// element.
+func parsePromo(n *html.Node) *Promo {
+	s := strings.ToLower(attr(n, "class") + " " + text(n))
+	p := new(Promo)
+	switch {
+	case strings.Contains(s, "sitewide") || strings.Contains(s, "site-wide") || strings.Contains(s, "site wide"):
+		p.Type = PromoSitewideFreeleech
+	case strings.Contains(s, "neutral"):
+		p.Type = PromoNeutralLeech
+	case strings.Contains(s, "half"):
+		p.Type = PromoHalfLeech
+	case strings.Contains(s, "freeleech") || strings.Contains(s, "free leech"):
+		p.Type = PromoFreeleech
+	default:
+		return nil
+	}
+	expires := attr(n, "data-expires")
+	var find func(*html.Node)
+	find = func(n *html.Node) {
+		for c := n.FirstChild; c != nil && expires == ""; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.DataAtom == atom.Time {
+				expires = attr(c, "datetime")
+			}
+			find(c)
+		}
+	}
+	if expires == "" {
+		find(n)
+	}
+	if i, err := strconv.ParseInt(expires, 10, 64); err == nil {
+		p.Expires = time.Unix(i, 0)
+	} else if t, err := time.Parse(time.RFC3339, expires); err == nil {
+		p.Expires = t
+	}
+	return p
+}
+
+// isPromo determines if the details page element is a promotion banner.
+func isPromo(n *html.Node) bool {
+	for _, class := range []string{"promo", "freeleech", "halfleech", "neutralleech", "sitewide"} {
+		if hasClass(n, class) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package tlapi
+
+import (
+	"strings"
+)
+
+// QueryBuilder builds a search query using boolean operators, rendered in the
+// site's (Solr) query syntax.
+//
+// Example:
+//
+//	req := tlapi.Search().WithQuery(tlapi.Query().Must("1080p").Not("x265"))
+type QueryBuilder struct {
+	terms []string
+}
+
+// Query creates a query builder.
+func Query() *QueryBuilder {
+	return &QueryBuilder{}
+}
+
+// Must adds terms that must all match.
+func (q *QueryBuilder) Must(terms ...string) *QueryBuilder {
+	for _, term := range terms {
+		if s := queryTerm(term); s != "" {
+			q.terms = append(q.terms, s)
+		}
+	}
+	return q
+}
+
+// Phrase adds an exact phrase that must match.
+func (q *QueryBuilder) Phrase(phrase string) *QueryBuilder {
+	if s := queryPhrase(phrase); s != "" {
+		q.terms = append(q.terms, s)
+	}
+	return q
+}
+
+// Any adds a group of terms, at least one of which must match.
+func (q *QueryBuilder) Any(terms ...string) *QueryBuilder {
+	var v []string
+	for _, term := range terms {
+		if s := queryTerm(term); s != "" {
+			v = append(v, s)
+		}
+	}
+	switch len(v) {
+	case 0:
+	case 1:
+		q.terms = append(q.terms, v[0])
+	default:
+		q.terms = append(q.terms, "("+strings.Join(v, " OR ")+")")
+	}
+	return q
+}
+
+// Not adds terms that must not match.
+func (q *QueryBuilder) Not(terms ...string) *QueryBuilder {
+	for _, term := range terms {
+		if s := queryTerm(term); s != "" {
+			q.terms = append(q.terms, "-"+s)
+		}
+	}
+	return q
+}
+
+// Terms returns the rendered query terms.
+func (q *QueryBuilder) Terms() []string {
+	return append([]string(nil), q.terms...)
+}
+
+// String satisfies the fmt.Stringer interface.
+func (q *QueryBuilder) String() string {
+	return strings.Join(q.terms, " ")
+}
+
+// WithQuery sets the search query from the query builder.
+func (req *SearchRequest) WithQuery(q *QueryBuilder) *SearchRequest {
+	r := req.clone()
+	r.Query = q.Terms()
+	return r
+}
+
+// queryTerm renders a term, quoting it as a phrase when it contains
+// whitespace.
+func queryTerm(term string) string {
+	if strings.ContainsAny(strings.TrimSpace(term), " \t\n\"") {
+		return queryPhrase(term)
+	}
+	return queryEscaper.Replace(strings.TrimSpace(term))
+}
+
+// queryPhrase renders a quoted phrase.
+func queryPhrase(phrase string) string {
+	phrase = strings.Join(strings.Fields(strings.ReplaceAll(phrase, `"`, " ")), " ")
+	if phrase == "" {
+		return ""
+	}
+	return `"` + phrase + `"`
+}
+
+// queryEscaper escapes query syntax characters in terms.
+var queryEscaper = strings.NewReplacer(
+	"+", `\+`,
+	"-", `\-`,
+	"!", `\!`,
+	"(", `\(`,
+	")", `\)`,
+	":", `\:`,
+	"^", `\^`,
+	"~", `\~`,
+	"*", `\*`,
+	"?", `\?`,
+	"{", `\{`,
+	"}", `\}`,
+	"[", `\[`,
+	"]", `\]`,
+	`\`, `\\`,
+	"|", `\|`,
+	"&", `\&`,
+	"/", `\/`,
+)
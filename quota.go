@@ -0,0 +1,205 @@
+package tlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Grab is a recorded torrent download.
+type Grab struct {
+	ID   int       `json:"id"`
+	Size int64     `json:"size"`
+	Time time.Time `json:"time"`
+}
+
+// GrabStore is the interface for persisting grabs (see the store subpackage).
+type GrabStore interface {
+	PutGrab(Grab) error
+	Grabs(since time.Time) []Grab
+}
+
+// Quota is a download quota. A zero limit is unlimited.
+type Quota struct {
+	Bytes    int64
+	Snatches int
+}
+
+// Usage is download usage in a quota period.
+type Usage struct {
+	Bytes    int64
+	Snatches int
+}
+
+// Quota periods.
+const (
+	QuotaDaily  = 24 * time.Hour
+	QuotaWeekly = 7 * 24 * time.Hour
+)
+
+// ErrQuotaExceeded is the quota exceeded error.
+var ErrQuotaExceeded = errors.New("download quota exceeded")
+
+// QuotaError is a quota error, returned when a download would exceed a quota.
+type QuotaError struct {
+	ID     int
+	Period time.Duration
+	Quota  Quota
+	Usage  Usage
+}
+
+// Error satisfies the error interface.
+func (err *QuotaError) Error() string {
+	return fmt.Sprintf("torrent %d: %v quota (%d bytes, %d snatches) used %d bytes, %d snatches: %v", err.ID, err.Period, err.Quota.Bytes, err.Quota.Snatches, err.Usage.Bytes, err.Usage.Snatches, ErrQuotaExceeded)
+}
+
+// Unwrap returns ErrQuotaExceeded.
+func (err *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// Downloader downloads torrents, enforcing daily and weekly byte and snatch
+// quotas over rolling periods, and an optional ratio guard. Grabs are
+// persisted to the store, so quotas are enforced across restarts. A
+// downloader is safe for concurrent use.
+type Downloader struct {
+	// Client is the client used to download.
+	Client *Client
+	// Store is the store grabs are persisted to. When nil, grabs are only
+	// tracked in memory.
+	Store GrabStore
+	// Daily is the quota for the last 24 hours.
+	Daily Quota
+	// Weekly is the quota for the last 7 days.
+	Weekly Quota
+	// Guard, when not nil, is checked before each download.
+	Guard *RatioGuard
+
+	mu      sync.Mutex
+	grabs   []Grab
+	pending []*Grab
+}
+
+// Usage returns the daily and weekly usage.
+func (d *Downloader) Usage() (Usage, Usage) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	return d.usage(now, QuotaDaily), d.usage(now, QuotaWeekly)
+}
+
+// Allow returns a QuotaError when downloading the torrent would exceed a
+// quota.
+func (d *Downloader) Allow(t Torrent) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.allow(t, time.Now())
+}
+
+// Download downloads the torrent when allowed by the quotas, and records
+// the grab. The grab is reserved against the quotas while downloading, so
+// that concurrent downloads do not exceed them. Grabs that cannot be
+// persisted to the store are logged, and tracked in memory.
+func (d *Downloader) Download(ctx context.Context, t Torrent) ([]byte, error) {
+	d.mu.Lock()
+	if err := d.allow(t, time.Now()); err != nil {
+		d.mu.Unlock()
+		return nil, err
+	}
+	g := &Grab{
+		ID:   t.ID,
+		Size: t.Size,
+		Time: time.Now(),
+	}
+	d.pending = append(d.pending, g)
+	d.mu.Unlock()
+	buf, err := d.download(ctx, t)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, p := range d.pending {
+		if p == g {
+			d.pending = append(d.pending[:i], d.pending[i+1:]...)
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	g.Time = time.Now()
+	if g.Size == 0 {
+		if m, err := ParseMetainfo(buf); err == nil {
+			g.Size = m.Length
+		}
+	}
+	if d.Store == nil {
+		d.grabs = append(d.grabs, *g)
+	} else if err := d.Store.PutGrab(*g); err != nil {
+		d.Client.logf("torrent %d: unable to store grab: %v", t.ID, err)
+		d.grabs = append(d.grabs, *g)
+	}
+	return buf, nil
+}
+
+// download checks the ratio guard and downloads the torrent.
+func (d *Downloader) download(ctx context.Context, t Torrent) ([]byte, error) {
+	if d.Guard != nil {
+		if err := d.Guard.Check(ctx, t); err != nil {
+			return nil, err
+		}
+	}
+	return d.Client.Torrent(ctx, t.ID)
+}
+
+// allow checks the torrent against the quotas.
+func (d *Downloader) allow(t Torrent, now time.Time) error {
+	for _, q := range []struct {
+		period time.Duration
+		quota  Quota
+	}{
+		{QuotaDaily, d.Daily},
+		{QuotaWeekly, d.Weekly},
+	} {
+		if q.quota == (Quota{}) {
+			continue
+		}
+		u := d.usage(now, q.period)
+		if q.quota.Snatches != 0 && u.Snatches+1 > q.quota.Snatches ||
+			q.quota.Bytes != 0 && u.Bytes+t.Size > q.quota.Bytes {
+			return &QuotaError{
+				ID:     t.ID,
+				Period: q.period,
+				Quota:  q.quota,
+				Usage:  u,
+			}
+		}
+	}
+	return nil
+}
+
+// usage returns the usage for the period ending now, including grabs in
+// progress. When a store is set, the in-memory grabs are the grabs that
+// could not be persisted.
+func (d *Downloader) usage(now time.Time, period time.Duration) Usage {
+	since := now.Add(-period)
+	var u Usage
+	add := func(g Grab) {
+		if g.Time.After(since) {
+			u.Bytes += g.Size
+			u.Snatches++
+		}
+	}
+	if d.Store != nil {
+		for _, g := range d.Store.Grabs(since) {
+			add(g)
+		}
+	}
+	for _, g := range d.grabs {
+		add(g)
+	}
+	for _, g := range d.pending {
+		add(*g)
+	}
+	return u
+}
@@ -0,0 +1,158 @@
+package tlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Account is account transfer statistics.
+type Account struct {
+	Uploaded   int64 `json:"uploaded"`
+	Downloaded int64 `json:"downloaded"`
+}
+
+// Ratio returns the account's share ratio. When nothing has been
+// downloaded, the ratio is +Inf for a positive upload, and 0 otherwise.
+func (a Account) Ratio() float64 {
+	if a.Downloaded == 0 {
+		if a.Uploaded > 0 {
+			return math.Inf(1)
+		}
+		return 0
+	}
+	return float64(a.Uploaded) / float64(a.Downloaded)
+}
+
+// Buffer returns the number of bytes that can be downloaded while keeping
+// the ratio at or above ratio (1 when 0).
+func (a Account) Buffer(ratio float64) int64 {
+	if ratio <= 0 {
+		ratio = 1
+	}
+	return int64(float64(a.Uploaded)/ratio) - a.Downloaded
+}
+
+// AccountSource is the interface for retrieving account statistics. The
+// client does not retrieve the account's profile, so statistics must be
+// supplied by the caller (for example, scraped from the profile page or
+// reported by a torrent client).
+type AccountSource interface {
+	Account(context.Context) (Account, error)
+}
+
+// AccountSourceFunc wraps a func as an AccountSource.
+type AccountSourceFunc func(context.Context) (Account, error)
+
+// Account satisfies the AccountSource interface.
+func (f AccountSourceFunc) Account(ctx context.Context) (Account, error) {
+	return f(ctx)
+}
+
+// ErrRatioGuard is the ratio guard error.
+var ErrRatioGuard = errors.New("ratio guard")
+
+// RatioError is a ratio guard error, returned when a download is blocked by
+// a ratio guard.
+type RatioError struct {
+	ID      int
+	Account Account
+	Reason  string
+}
+
+// Error satisfies the error interface.
+func (err *RatioError) Error() string {
+	return fmt.Sprintf("torrent %d: %v: %s", err.ID, ErrRatioGuard, err.Reason)
+}
+
+// Unwrap returns ErrRatioGuard.
+func (err *RatioError) Unwrap() error {
+	return ErrRatioGuard
+}
+
+// RatioGuard checks account statistics against thresholds before
+// non-freeleech downloads. A ratio guard is safe for concurrent use.
+type RatioGuard struct {
+	// Source is the account statistics source (see AccountSource).
+	Source AccountSource
+	// MinRatio is the minimum ratio required for non-freeleech downloads.
+	MinRatio float64
+	// MinBuffer is the minimum buffer (at MinRatio) that must remain after
+	// a non-freeleech download.
+	MinBuffer int64
+	// Downgrade enables freeleech-only mode when the account is below the
+	// thresholds (see FreeleechOnly).
+	Downgrade bool
+	// MaxAge is how long retrieved statistics are reused.
+	MaxAge time.Duration
+
+	mu      sync.Mutex
+	account Account
+	last    time.Time
+}
+
+// Check returns a RatioError when the account's statistics do not allow
+// downloading the torrent. Torrents with a free promotion (see Torrent.Promo)
+// are always allowed.
+func (g *RatioGuard) Check(ctx context.Context, t Torrent) error {
+	if t.Promo().Free() {
+		return nil
+	}
+	a, err := g.get(ctx)
+	if err != nil {
+		return fmt.Errorf("torrent %d: %w", t.ID, err)
+	}
+	if reason := g.check(a, t.Size); reason != "" {
+		return &RatioError{
+			ID:      t.ID,
+			Account: a,
+			Reason:  reason,
+		}
+	}
+	return nil
+}
+
+// FreeleechOnly returns true when Downgrade is enabled and the account is
+// below the thresholds, so callers can restrict searches to freeleech
+// torrents.
+func (g *RatioGuard) FreeleechOnly(ctx context.Context) (bool, error) {
+	if !g.Downgrade {
+		return false, nil
+	}
+	a, err := g.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	return g.check(a, 0) != "", nil
+}
+
+// check checks the account against the thresholds after downloading size
+// bytes, returning the reason when below.
+func (g *RatioGuard) check(a Account, size int64) string {
+	if g.MinRatio != 0 && a.Ratio() < g.MinRatio {
+		return fmt.Sprintf("ratio %.3f below %.3f", a.Ratio(), g.MinRatio)
+	}
+	if buf := a.Buffer(g.MinRatio) - size; g.MinBuffer != 0 && buf < g.MinBuffer {
+		return fmt.Sprintf("buffer %d below %d", buf, g.MinBuffer)
+	}
+	return ""
+}
+
+// get returns the account statistics, retrieving them from the source when
+// older than MaxAge.
+func (g *RatioGuard) get(ctx context.Context) (Account, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.last.IsZero() && time.Since(g.last) < g.MaxAge {
+		return g.account, nil
+	}
+	a, err := g.Source.Account(ctx)
+	if err != nil {
+		return Account{}, err
+	}
+	g.account, g.last = a, time.Now()
+	return a, nil
+}
@@ -0,0 +1,359 @@
+package tlapi
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Release is release information parsed from a release name.
+type Release struct {
+	Title      string `json:"title"`
+	Year       int    `json:"year,omitempty"`
+	Season     int    `json:"season,omitempty"`
+	Episode    int    `json:"episode,omitempty"`
+	Resolution string `json:"resolution,omitempty"`
+	Source     string `json:"source,omitempty"`
+	Codec      string `json:"codec,omitempty"`
+	HDR        string `json:"hdr,omitempty"`
+	Remux      bool   `json:"remux,omitempty"`
+	Proper     bool   `json:"proper,omitempty"`
+	Group      string `json:"group,omitempty"`
+}
+
+// Resolution values.
+const (
+	Resolution2160p = "2160p"
+	Resolution1080p = "1080p"
+	Resolution720p  = "720p"
+	Resolution576p  = "576p"
+	Resolution480p  = "480p"
+)
+
+// Source values.
+const (
+	SourceBluRay = "BluRay"
+	SourceWEBDL  = "WEB-DL"
+	SourceWEBRip = "WEBRip"
+	SourceHDTV   = "HDTV"
+	SourceDVD    = "DVD"
+	SourceHDRip  = "HDRip"
+	SourceCam    = "CAM"
+)
+
+// releaseTokens map lower case release name tokens to their release field
+// values.
+var releaseTokens = map[string]struct {
+	field string
+	value string
+}{
+	"2160p":   {"resolution", Resolution2160p},
+	"4k":      {"resolution", Resolution2160p},
+	"uhd":     {"resolution", Resolution2160p},
+	"1080p":   {"resolution", Resolution1080p},
+	"1080i":   {"resolution", Resolution1080p},
+	"720p":    {"resolution", Resolution720p},
+	"576p":    {"resolution", Resolution576p},
+	"480p":    {"resolution", Resolution480p},
+	"bluray":  {"source", SourceBluRay},
+	"blu-ray": {"source", SourceBluRay},
+	"bdrip":   {"source", SourceBluRay},
+	"brrip":   {"source", SourceBluRay},
+	"web-dl":  {"source", SourceWEBDL},
+	"webdl":   {"source", SourceWEBDL},
+	"web":     {"source", SourceWEBDL},
+	"webrip":  {"source", SourceWEBRip},
+	"hdtv":    {"source", SourceHDTV},
+	"dvdrip":  {"source", SourceDVD},
+	"dvd":     {"source", SourceDVD},
+	"dvdr":    {"source", SourceDVD},
+	"hdrip":   {"source", SourceHDRip},
+	"cam":     {"source", SourceCam},
+	"hdcam":   {"source", SourceCam},
+	"ts":      {"source", SourceCam},
+	"x264":    {"codec", "x264"},
+	"h264":    {"codec", "x264"},
+	"h.264":   {"codec", "x264"},
+	"avc":     {"codec", "x264"},
+	"x265":    {"codec", "x265"},
+	"h265":    {"codec", "x265"},
+	"h.265":   {"codec", "x265"},
+	"hevc":    {"codec", "x265"},
+	"xvid":    {"codec", "XviD"},
+	"av1":     {"codec", "AV1"},
+	"vc-1":    {"codec", "VC-1"},
+	"hdr":     {"hdr", "HDR"},
+	"hdr10":   {"hdr", "HDR10"},
+	"hdr10+":  {"hdr", "HDR10+"},
+	"dv":      {"hdr", "DV"},
+	"dovi":    {"hdr", "DV"},
+	"remux":   {"remux", ""},
+	"proper":  {"proper", ""},
+	"repack":  {"proper", ""},
+}
+
+// releaseGroupRE matches the release group at the end of a release name.
+var releaseGroupRE = regexp.MustCompile(`-([A-Za-z0-9][A-Za-z0-9_]*)(?:\.(?:mkv|mp4|avi|torrent))?$`)
+
+// releaseSplitRE splits release names into tokens.
+var releaseSplitRE = regexp.MustCompile(`[\s._()\[\]]+`)
+
+// ParseRelease parses release information from a release name (for example,
+// "The.Movie.2019.2160p.UHD.BluRay.REMUX.HDR.HEVC.Atmos-GROUP").
+func ParseRelease(name string) Release {
+	var r Release
+	name = strings.TrimSpace(name)
+	if m := releaseGroupRE.FindStringSubmatchIndex(name); m != nil {
+		r.Group = name[m[2]:m[3]]
+		name = name[:m[0]]
+	}
+	r.Season, r.Episode, _ = ParseEpisode(name)
+	var tokens []string
+	for _, tok := range releaseSplitRE.Split(name, -1) {
+		if tok != "" {
+			tokens = append(tokens, tok)
+		}
+	}
+	// title ends at the last year before the first episode or resolution
+	// token, falling back to the first quality token
+	end, first := len(tokens), len(tokens)
+	var scratch Release
+	for i, tok := range tokens {
+		field := scratch.parseToken(tok)
+		if (field == "episode" || field == "resolution") && end == len(tokens) {
+			end = i
+		}
+		if field != "" && first == len(tokens) && i != 0 {
+			first = i
+		}
+	}
+	title := tokens[:end]
+	for i := end - 1; i > 0; i-- {
+		if y, ok := parseYear(tokens[i]); ok {
+			r.Year, title = y, tokens[:i]
+			break
+		}
+	}
+	if r.Year == 0 && end == len(tokens) {
+		title = tokens[:first]
+	}
+	for _, tok := range tokens[len(title):] {
+		r.parseToken(tok)
+	}
+	r.Title = strings.Join(title, " ")
+	return r
+}
+
+// parseToken parses a release name token into the release, returning the
+// release field for quality and episode tokens.
+func (r *Release) parseToken(tok string) string {
+	if episodeRE.MatchString(tok) {
+		return "episode"
+	}
+	lower := strings.ToLower(tok)
+	// handle tokens joined with '-' (such as "WEB-DL" or "DTS-HD")
+	for _, t := range append([]string{lower}, strings.Split(lower, "-")...) {
+		v, ok := releaseTokens[t]
+		if !ok {
+			continue
+		}
+		switch v.field {
+		case "resolution":
+			if r.Resolution == "" {
+				r.Resolution = v.value
+			}
+		case "source":
+			if r.Source == "" {
+				r.Source = v.value
+			}
+		case "codec":
+			if r.Codec == "" {
+				r.Codec = v.value
+			}
+		case "hdr":
+			if r.HDR == "" {
+				r.HDR = v.value
+			}
+		case "remux":
+			r.Remux = true
+		case "proper":
+			r.Proper = true
+		}
+		return v.field
+	}
+	return ""
+}
+
+// parseYear parses a release year token.
+func parseYear(tok string) (int, bool) {
+	if len(tok) != 4 || !strings.HasPrefix(tok, "19") && !strings.HasPrefix(tok, "20") {
+		return 0, false
+	}
+	y, err := strconv.Atoi(tok)
+	return y, err == nil
+}
+
+// Quality returns a quality label for the release (for example, "2160p
+// BluRay Remux").
+func (r Release) Quality() string {
+	var v []string
+	if r.Resolution != "" {
+		v = append(v, r.Resolution)
+	}
+	if r.Source != "" {
+		v = append(v, r.Source)
+	}
+	if r.Remux {
+		v = append(v, "Remux")
+	}
+	if r.HDR != "" {
+		v = append(v, r.HDR)
+	}
+	if len(v) == 0 {
+		return "Unknown"
+	}
+	return strings.Join(v, " ")
+}
+
+// QualityRank returns a rank for the release's quality, higher being better.
+func (r Release) QualityRank() int {
+	rank := resolutionRanks[r.Resolution]*100 + sourceRanks[r.Source]*10
+	if r.Remux {
+		rank += 5
+	}
+	if r.HDR != "" {
+		rank += 2
+	}
+	if r.Proper {
+		rank++
+	}
+	return rank
+}
+
+// resolutionRanks are the resolution ranks.
+var resolutionRanks = map[string]int{
+	Resolution480p:  1,
+	Resolution576p:  2,
+	Resolution720p:  3,
+	Resolution1080p: 4,
+	Resolution2160p: 5,
+}
+
+// sourceRanks are the source ranks.
+var sourceRanks = map[string]int{
+	SourceCam:    1,
+	SourceDVD:    2,
+	SourceHDTV:   3,
+	SourceHDRip:  4,
+	SourceWEBRip: 5,
+	SourceWEBDL:  6,
+	SourceBluRay: 7,
+}
+
+// NormalizeTitle normalizes a title for comparison, lower casing it and
+// collapsing punctuation and whitespace.
+func NormalizeTitle(title string) string {
+	var b strings.Builder
+	space := false
+	for _, c := range strings.ToLower(title) {
+		switch {
+		case c == '&':
+			c = ' '
+			b.WriteString(" and")
+		case c == '\'':
+			continue
+		case !('a' <= c && c <= 'z' || '0' <= c && c <= '9' || c > 127):
+			c = ' '
+		}
+		if c == ' ' {
+			space = true
+			continue
+		}
+		if space && b.Len() != 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteRune(c)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Edition is a torrent in an edition group, with its parsed release and
+// quality label.
+type Edition struct {
+	Torrent Torrent `json:"torrent"`
+	Release Release `json:"release"`
+	Quality string  `json:"quality"`
+}
+
+// EditionGroup is a group of torrents referring to the same content.
+type EditionGroup struct {
+	Key      string    `json:"key"`
+	Title    string    `json:"title"`
+	Year     int       `json:"year,omitempty"`
+	Editions []Edition `json:"editions"`
+}
+
+// Best returns the best edition in the group, by quality rank then seeders.
+func (g EditionGroup) Best() Edition {
+	best := g.Editions[0]
+	for _, e := range g.Editions[1:] {
+		a, b := e.Release.QualityRank(), best.Release.QualityRank()
+		if a > b || a == b && e.Torrent.Seeders > best.Torrent.Seeders {
+			best = e
+		}
+	}
+	return best
+}
+
+// GroupEditions groups the torrents that refer to the same content (by IMDb
+// id, TVMaze id, or normalized title and year), labeling each torrent's
+// quality. TV episodes are grouped per episode. Groups are returned in order
+// of first appearance, with editions ordered by quality rank (descending).
+func GroupEditions(torrents []Torrent) []EditionGroup {
+	var groups []EditionGroup
+	index := make(map[string]int)
+	for _, t := range torrents {
+		r := ParseRelease(t.Name)
+		key := editionKey(t, r)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, EditionGroup{
+				Key:   key,
+				Title: r.Title,
+				Year:  r.Year,
+			})
+		}
+		groups[i].Editions = append(groups[i].Editions, Edition{
+			Torrent: t,
+			Release: r,
+			Quality: r.Quality(),
+		})
+	}
+	for _, g := range groups {
+		sort.SliceStable(g.Editions, func(i, j int) bool {
+			return g.Editions[i].Release.QualityRank() > g.Editions[j].Release.QualityRank()
+		})
+	}
+	return groups
+}
+
+// editionKey returns the edition group key for the torrent.
+func editionKey(t Torrent, r Release) string {
+	var key string
+	switch {
+	case NormalizeIMDbID(t.ImdbID) != "":
+		key = "imdb:" + NormalizeIMDbID(t.ImdbID)
+	case t.TvmazeID != "" && t.TvmazeID != "0":
+		key = "tvmaze:" + t.TvmazeID
+	default:
+		key = "title:" + NormalizeTitle(r.Title) + ":" + strconv.Itoa(r.Year)
+	}
+	if r.Season != 0 || r.Episode != 0 {
+		key += ":s" + strconv.Itoa(r.Season) + "e" + strconv.Itoa(r.Episode)
+	}
+	return key
+}
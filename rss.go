@@ -0,0 +1,38 @@
+package tlapi
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// WithPasskey is a TL client option to set the account's passkey, used to
+// build passkey-based RSS download urls (see DownloadURL).
+func WithPasskey(passkey string) Option {
+	return func(cl *Client) {
+		cl.passkey = passkey
+	}
+}
+
+// DownloadURL returns the url for downloading the torrent's .torrent file.
+// When the client has a passkey (see WithPasskey and Passkey), the site's
+// passkey-based RSS download url is returned, which can be fetched without
+// cookies (for example, by a torrent client reading a generated RSS or
+// Torznab feed).
+// Otherwise, the cookie-authenticated download url is returned.
+func (cl *Client) DownloadURL(t Torrent) string {
+	cl.passkeyMu.Lock()
+	passkey := cl.passkey
+	cl.passkeyMu.Unlock()
+	if passkey != "" {
+		return RSSDownloadURL(cl.base, t, passkey)
+	}
+	return cl.base + "/download/" + strconv.Itoa(t.ID) + "/" + url.PathEscape(torrentFilename(t))
+}
+
+// RSSDownloadURL returns the site's passkey-based RSS download url for the
+// torrent on the base url (for example, "https://www.torrentleech.org").
+// Anyone with the url can download the torrent as the account, so it should
+// only be shared with trusted consumers.
+func RSSDownloadURL(baseURL string, t Torrent, passkey string) string {
+	return baseURL + "/rss/download/" + strconv.Itoa(t.ID) + "/" + url.PathEscape(passkey) + "/" + url.PathEscape(torrentFilename(t))
+}
@@ -0,0 +1,174 @@
+package tlapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// Rule is a torrent filter rule. All set conditions must match.
+type Rule struct {
+	Name string `json:"name"`
+	// Match is a RE2 pattern the torrent name must match (for example,
+	// `(?i)^the\.movie\.`).
+	Match string `json:"match,omitempty"`
+	// Exclude is a RE2 pattern the torrent name must not match.
+	Exclude string `json:"exclude,omitempty"`
+	// Groups is the release group allow and deny list.
+	Groups     GroupFilter `json:"groups,omitempty"`
+	Categories []int       `json:"categories,omitempty"`
+	// Tags are the tags the torrent must have.
+	Tags       []string `json:"tags,omitempty"`
+	MinSize    int64    `json:"minSize,omitempty"`
+	MaxSize    int64    `json:"maxSize,omitempty"`
+	MinSeeders int      `json:"minSeeders,omitempty"`
+
+	match   *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// RuleError is a rule error.
+type RuleError struct {
+	Index int
+	Name  string
+	Field string
+	Err   error
+}
+
+// Error satisfies the error interface.
+func (err *RuleError) Error() string {
+	return fmt.Sprintf("rule %d (%q): %s: %v", err.Index, err.Name, err.Field, err.Err)
+}
+
+// Unwrap returns the underlying error.
+func (err *RuleError) Unwrap() error {
+	return err.Err
+}
+
+// Rules are torrent filter rules.
+type Rules []Rule
+
+// LoadRules loads and compiles rules from a JSON array.
+func LoadRules(r io.Reader) (Rules, error) {
+	var rules Rules
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, err
+	}
+	return rules.Compile()
+}
+
+// Compile returns a copy of the rules with their patterns compiled, or a
+// RuleError for the first invalid pattern. Rules should be compiled before
+// use, otherwise patterns are compiled on every match.
+func (rules Rules) Compile() (Rules, error) {
+	v := make(Rules, len(rules))
+	for i, rule := range rules {
+		var err error
+		if rule.match, err = compileRule(rule.Match); err != nil {
+			return nil, &RuleError{Index: i, Name: rule.Name, Field: "match", Err: err}
+		}
+		if rule.exclude, err = compileRule(rule.Exclude); err != nil {
+			return nil, &RuleError{Index: i, Name: rule.Name, Field: "exclude", Err: err}
+		}
+		v[i] = rule
+	}
+	return v, nil
+}
+
+// compileRule compiles a rule pattern.
+func compileRule(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+// Match returns the first rule matching the torrent.
+func (rules Rules) Match(t Torrent) (Rule, bool) {
+	for _, rule := range rules {
+		if rule.MatchTorrent(t) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Filter returns a filter func for torrents matching any of the rules,
+// suitable for use with SearchRequest.WithFilter.
+func (rules Rules) Filter() func(Torrent) bool {
+	return func(t Torrent) bool {
+		_, ok := rules.Match(t)
+		return ok
+	}
+}
+
+// RuleTest is a rule test result.
+type RuleTest struct {
+	Rule    string   `json:"rule"`
+	Matches []string `json:"matches"`
+}
+
+// Test reports which of the sample names each rule matches, for validating
+// rules before use. Only the name conditions (Match, Exclude, and Groups)
+// are tested.
+func (rules Rules) Test(names []string) []RuleTest {
+	v := make([]RuleTest, len(rules))
+	for i, rule := range rules {
+		v[i].Rule = rule.Name
+		for _, name := range names {
+			if rule.MatchName(name) {
+				v[i].Matches = append(v[i].Matches, name)
+			}
+		}
+	}
+	return v
+}
+
+// MatchName returns true when the name matches the rule's name conditions
+// (Match, Exclude, and Groups).
+func (rule Rule) MatchName(name string) bool {
+	return matchRule(rule.match, rule.Match, name, true) &&
+		!matchRule(rule.exclude, rule.Exclude, name, false) &&
+		rule.Groups.MatchGroup(ParseRelease(name).Group)
+}
+
+// matchRule matches the name against the compiled pattern, compiling it
+// when necessary. An empty pattern matches when empty is true.
+func matchRule(re *regexp.Regexp, pattern, name string, empty bool) bool {
+	switch {
+	case pattern == "":
+		return empty
+	case re == nil:
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return false
+		}
+	}
+	return re.MatchString(name)
+}
+
+// MatchTorrent returns true when the torrent matches the rule.
+func (rule Rule) MatchTorrent(t Torrent) bool {
+	if !rule.MatchName(t.Name) ||
+		rule.MinSize != 0 && t.Size < rule.MinSize ||
+		rule.MaxSize != 0 && t.Size > rule.MaxSize ||
+		t.Seeders < rule.MinSeeders {
+		return false
+	}
+	if len(rule.Categories) != 0 {
+		ok := false
+		for _, c := range rule.Categories {
+			ok = ok || c == t.CategoryID
+		}
+		if !ok {
+			return false
+		}
+	}
+	for _, tag := range rule.Tags {
+		if !t.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
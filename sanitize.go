@@ -0,0 +1,91 @@
+package tlapi
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Redacted is the replacement for values redacted by a sanitizer.
+const Redacted = "REDACTED"
+
+// Sanitizer redacts secrets (such as cookie values, passkeys, and user
+// identifiers) from log and debug output, so that it can be shared safely.
+// Patterns and literal secrets can be added for custom values. A sanitizer
+// is safe for concurrent use.
+type Sanitizer struct {
+	mu       sync.RWMutex
+	patterns []sanitizePattern
+	secrets  []string
+}
+
+// sanitizePattern is a sanitizer pattern and replacement.
+type sanitizePattern struct {
+	re   *regexp.Regexp
+	repl string
+}
+
+// NewSanitizer creates a sanitizer redacting cookie and authorization
+// header values, the site's cookie, passkey, and user id parameters (such
+// as "tlpass=..." or "passkey=..."), and passkeys (32 hex digits) in urls
+// and paths.
+func NewSanitizer() *Sanitizer {
+	s := new(Sanitizer)
+	s.Add(regexp.MustCompile(`(?im)^(\s*(?:set-)?cookie:\s*|\s*authorization:\s*)(.+)$`), "${1}"+Redacted)
+	s.Add(regexp.MustCompile(`(?i)\b(phpsessid|tluid|tlpass|cf_clearance|__cf_bm|passkey|torrent_pass|authkey|rsskey|uid|user_?id|pass|password|token|api_?key)=([^;&\s"',]+)`), "${1}="+Redacted)
+	s.Add(regexp.MustCompile(`\b[0-9a-fA-F]{32}\b`), Redacted)
+	return s
+}
+
+// Add adds a pattern to the sanitizer, replacing matches with repl (as with
+// regexp.ReplaceAllString).
+func (s *Sanitizer) Add(re *regexp.Regexp, repl string) *Sanitizer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.patterns = append(s.patterns, sanitizePattern{re: re, repl: repl})
+	return s
+}
+
+// AddSecret adds literal secrets (such as the account's own cookie values
+// or user name) to the sanitizer. Empty secrets are ignored.
+func (s *Sanitizer) AddSecret(secrets ...string) *Sanitizer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, secret := range secrets {
+		if secret != "" {
+			s.secrets = append(s.secrets, secret)
+		}
+	}
+	return s
+}
+
+// Sanitize returns str with secrets redacted.
+func (s *Sanitizer) Sanitize(str string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, secret := range s.secrets {
+		str = strings.ReplaceAll(str, secret, Redacted)
+	}
+	for _, p := range s.patterns {
+		str = p.re.ReplaceAllString(str, p.repl)
+	}
+	return str
+}
+
+// Logf returns a log func that sanitizes messages before passing them to
+// logf.
+func (s *Sanitizer) Logf(logf func(string, ...interface{})) func(string, ...interface{}) {
+	return func(format string, v ...interface{}) {
+		logf("%s", s.Sanitize(fmt.Sprintf(format, v...)))
+	}
+}
+
+// WithSanitizer is a TL client option to set the sanitizer applied to the
+// client's log output (see WithLogf). Log output is sanitized with
+// NewSanitizer by default; a nil sanitizer disables sanitizing.
+func WithSanitizer(s *Sanitizer) Option {
+	return func(cl *Client) {
+		cl.sanitizer = s
+	}
+}
@@ -0,0 +1,154 @@
+package tlapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// SavedSearch is a named search request.
+type SavedSearch struct {
+	Name    string
+	Request *SearchRequest
+}
+
+// MarshalJSON satisfies the json.Marshaler interface.
+func (s SavedSearch) MarshalJSON() ([]byte, error) {
+	var params searchParams
+	if s.Request != nil {
+		params = newSearchParams(s.Request)
+	}
+	return json.Marshal(savedSearchJSON{
+		Name:    s.Name,
+		Request: params,
+	})
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface.
+func (s *SavedSearch) UnmarshalJSON(buf []byte) error {
+	var v savedSearchJSON
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return err
+	}
+	*s = SavedSearch{
+		Name:    v.Name,
+		Request: v.Request.request(),
+	}
+	return nil
+}
+
+// savedSearchJSON is the json representation of a saved search.
+type savedSearchJSON struct {
+	Name    string       `json:"name"`
+	Request searchParams `json:"request"`
+}
+
+// searchParams are the serialized search request filter parameters.
+type searchParams struct {
+	Categories []int             `json:"categories,omitempty" yaml:"categories,omitempty"`
+	Facets     map[string]string `json:"facets,omitempty" yaml:"facets,omitempty"`
+	Query      []string          `json:"query,omitempty" yaml:"query,omitempty"`
+	Added      string            `json:"added,omitempty" yaml:"added,omitempty"`
+	OrderBy    string            `json:"orderBy,omitempty" yaml:"orderBy,omitempty"`
+	Order      string            `json:"order,omitempty" yaml:"order,omitempty"`
+	Page       int               `json:"page,omitempty" yaml:"page,omitempty"`
+}
+
+// newSearchParams creates search params for the search request.
+func newSearchParams(req *SearchRequest) searchParams {
+	return searchParams{
+		Categories: req.Categories,
+		Facets:     req.Facets,
+		Query:      req.Query,
+		Added:      req.Added,
+		OrderBy:    req.OrderBy,
+		Order:      req.Order,
+		Page:       req.Page,
+	}
+}
+
+// request creates a search request for the search params.
+func (params searchParams) request() *SearchRequest {
+	req := Search(params.Query...)
+	req.Categories = params.Categories
+	req.Facets = params.Facets
+	req.Added = params.Added
+	req.OrderBy = params.OrderBy
+	req.Order = params.Order
+	if params.Page != 0 {
+		req.Page = params.Page
+	}
+	return req
+}
+
+// MarshalJSON satisfies the json.Marshaler interface. Only the request's
+// filter fields are marshaled (not its cursor state or delays), as the
+// canonical serialized query format shared by saved searches, rule files,
+// and servers.
+func (req *SearchRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newSearchParams(req))
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface, resetting the
+// request's cursor state and validating the unmarshaled values.
+func (req *SearchRequest) UnmarshalJSON(buf []byte) error {
+	var params searchParams
+	if err := json.Unmarshal(buf, &params); err != nil {
+		return err
+	}
+	return req.setParams(params)
+}
+
+// MarshalYAML satisfies the yaml.Marshaler interface (of gopkg.in/yaml.v2
+// and v3), with the same fields as MarshalJSON.
+func (req *SearchRequest) MarshalYAML() (interface{}, error) {
+	return newSearchParams(req), nil
+}
+
+// UnmarshalYAML satisfies the yaml.Unmarshaler interface (of gopkg.in/yaml.v2,
+// and the obsolete interface of v3), with the same fields as UnmarshalJSON.
+func (req *SearchRequest) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var params searchParams
+	if err := unmarshal(&params); err != nil {
+		return err
+	}
+	return req.setParams(params)
+}
+
+// setParams sets the request's filter fields from the search params,
+// resetting its cursor state.
+func (req *SearchRequest) setParams(params searchParams) error {
+	r := params.request()
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	req.Categories, req.Facets, req.Query = r.Categories, r.Facets, r.Query
+	req.Added, req.OrderBy, req.Order, req.Page = r.Added, r.OrderBy, r.Order, r.Page
+	if req.d == 0 {
+		req.d = r.d
+	}
+	req.pager = nil
+	return req.Validate()
+}
+
+// SaveSearches writes the saved searches to the writer as json.
+func SaveSearches(w io.Writer, searches []SavedSearch) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(searches)
+}
+
+// LoadSearches reads saved searches written by SaveSearches from the reader.
+func LoadSearches(r io.Reader) ([]SavedSearch, error) {
+	var searches []SavedSearch
+	if err := json.NewDecoder(r).Decode(&searches); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for _, s := range searches {
+		if seen[s.Name] {
+			return nil, fmt.Errorf("duplicate saved search %q", s.Name)
+		}
+		seen[s.Name] = true
+	}
+	return searches, nil
+}
@@ -0,0 +1,236 @@
+package tlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// WithHTMLFallback is a TL client option to fall back to scraping the
+// regular html browse pages when the json list endpoint is unavailable or
+// its response cannot be decoded. Scraped torrents only include the fields
+// shown in the browse table.
+func WithHTMLFallback(fallback bool) Option {
+	return func(cl *Client) {
+		cl.htmlFallback = fallback
+	}
+}
+
+// useFallback determines if the search request error should fall back to
+// scraping.
+func useFallback(err error) bool {
+	var serr *StatusError
+	return errors.Is(err, ErrUnexpectedHTML) ||
+		errors.As(err, &serr) && (serr.StatusCode == http.StatusNotFound || serr.StatusCode == http.StatusGone)
+}
+
+// scrape scrapes the html browse page for the search request.
+func (req *SearchRequest) scrape(ctx context.Context, cl *Client, cause error) (*SearchResponse, error) {
+	httpReq, err := http.NewRequest("GET", cl.url("/torrents/browse/index"+req.path()), nil)
+	if err != nil {
+		return nil, req.wrapErr(err)
+	}
+	res, err := cl.do(ctx, httpReq)
+	if err != nil {
+		return nil, req.wrapErr(err)
+	}
+	defer res.Body.Close()
+	torrents, more, err := ParseBrowseHTML(res.Body)
+	switch {
+	case err != nil:
+		return nil, req.wrapErr(newRequestError(httpReq, err))
+	case torrents == nil:
+		return nil, req.wrapErr(fmt.Errorf("html fallback found no torrent table: %w", cause))
+	}
+	page := req.Page
+	if page == 0 {
+		page = 1
+	}
+	v := &SearchResponse{
+		Page:        page,
+		PerPage:     len(torrents),
+		NumFound:    (page-1)*len(torrents) + len(torrents),
+		TorrentList: torrents,
+	}
+	if more {
+		v.NumFound++
+	}
+	return v, nil
+}
+
+// browse html patterns.
+var (
+	browseTorrentRE  = regexp.MustCompile(`/torrent/(\d+)`)
+	browseDownloadRE = regexp.MustCompile(`/download/\d+/([^/?#]+)`)
+	browseCategoryRE = regexp.MustCompile(`/categories/(\d+)`)
+	browsePageRE     = regexp.MustCompile(`/page/(\d+)`)
+	browseSizeRE     = regexp.MustCompile(`(?i)^([\d.,]+)\s*([KMGTP]?i?B)$`)
+)
+
+// ParseBrowseHTML parses torrents from a html browse page, returning true
+// when the page links to a following page. Rows of the torrent table are
+// identified by their torrent link, and fields are read from cells with
+// known classes (such as "seeders" or "size"). Returns nil torrents when
+// the page does not contain any torrent rows.
+func ParseBrowseHTML(r io.Reader) ([]Torrent, bool, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, false, err
+	}
+	var torrents []Torrent
+	page, maxPage := 0, 0
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "tr":
+				if t, ok := parseBrowseRow(n); ok {
+					torrents = append(torrents, t)
+					return
+				}
+			case "a":
+				if m := browsePageRE.FindStringSubmatch(attr(n, "href")); m != nil {
+					if i, _ := strconv.Atoi(m[1]); i > maxPage {
+						maxPage = i
+					}
+					if hasClass(n, "active") || hasClass(n, "current") {
+						page, _ = strconv.Atoi(m[1])
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	if page == 0 {
+		page = 1
+	}
+	return torrents, maxPage > page, nil
+}
+
+// parseBrowseRow parses a torrent from a browse table row.
+func parseBrowseRow(tr *html.Node) (Torrent, bool) {
+	var t Torrent
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		href := attr(n, "href")
+		switch {
+		case n.Data == "a" && t.Name == "" && browseTorrentRE.MatchString(href):
+			if name := text(n); name != "" {
+				t.ID, _ = strconv.Atoi(browseTorrentRE.FindStringSubmatch(href)[1])
+				t.Name = name
+			}
+		case n.Data == "a" && browseDownloadRE.MatchString(href):
+			t.Filename, _ = url.PathUnescape(browseDownloadRE.FindStringSubmatch(href)[1])
+		case n.Data == "a" && t.CategoryID == 0 && browseCategoryRE.MatchString(href):
+			t.CategoryID, _ = strconv.Atoi(browseCategoryRE.FindStringSubmatch(href)[1])
+		case hasClass(n, "seeders"):
+			t.Seeders = parseCount(text(n))
+		case hasClass(n, "leechers"):
+			t.Leechers = parseCount(text(n))
+		case hasClass(n, "completed") || hasClass(n, "snatched"):
+			t.Completed = parseCount(text(n))
+		case hasClass(n, "size") || hasClass(n, "td-size"):
+			t.Size = parseSize(text(n))
+		case hasClass(n, "added") || hasClass(n, "date"):
+			if ts, err := time.Parse(timefmt, text(n)); err == nil {
+				t.AddedTimestamp = ts
+			}
+		case hasClass(n, "tag"):
+			if s := text(n); s != "" {
+				t.Tags = append(t.Tags, s)
+			}
+		case hasClass(n, "freeleech") && !t.HasTag(TagFreeleech):
+			t.Tags = append(t.Tags, TagFreeleech)
+		case isNuke(n):
+			t.Nuked, t.NukeReason = true, nukeReason(n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for c := tr.FirstChild; c != nil; c = c.NextSibling {
+		walk(c)
+	}
+	if id := atoi(attr(tr, "data-tid")); id != 0 {
+		t.ID = id
+	}
+	if id := atoi(attr(tr, "data-category-id")); id != 0 {
+		t.CategoryID = id
+	}
+	return t, t.ID != 0 && t.Name != ""
+}
+
+// attr returns the node's attribute value.
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasClass determines if the node has the class.
+func hasClass(n *html.Node, class string) bool {
+	for _, s := range strings.Fields(attr(n, "class")) {
+		if s == class {
+			return true
+		}
+	}
+	return false
+}
+
+// text returns the node's collapsed text content.
+func text(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			b.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// parseSize parses a display size (for example, "1.46 GB") as bytes, using
+// binary units.
+func parseSize(s string) int64 {
+	m := browseSizeRE.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0
+	}
+	f, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	i := strings.Index("BKMGTP", strings.ToUpper(m[2][:1]))
+	for ; i > 0; i-- {
+		f *= 1024
+	}
+	return int64(f)
+}
+
+// parseCount parses a display count (for example, "1,234").
+func parseCount(s string) int {
+	return atoi(strings.ReplaceAll(s, ",", ""))
+}
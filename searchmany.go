@@ -0,0 +1,143 @@
+package tlapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueryError is a search query error.
+type QueryError struct {
+	// Index is the query's index in the requests.
+	Index int
+	Err   error
+}
+
+// Error satisfies the error interface.
+func (err *QueryError) Error() string {
+	return fmt.Sprintf("query %d: %v", err.Index, err.Err)
+}
+
+// Unwrap returns the underlying error.
+func (err *QueryError) Unwrap() error {
+	return err.Err
+}
+
+// QueryErrors are search query errors.
+type QueryErrors []*QueryError
+
+// Error satisfies the error interface.
+func (errs QueryErrors) Error() string {
+	v := make([]string, len(errs))
+	for i, err := range errs {
+		v[i] = err.Error()
+	}
+	return fmt.Sprintf("%d query(s) failed: %s", len(errs), strings.Join(v, "; "))
+}
+
+// SearchMany retrieves all results for each of the search requests, running
+// up to workers queries concurrently, and returns the torrents for each
+// request in request order. Identical queries (without filters) are only
+// executed once, and duplicate torrents are removed from each query's
+// results. All requests are subject to the client's limiter, and successive
+// queries are started no closer together than the client's minimum next
+// delay (see WithMinNextDelay). The requests' cursor state is not used or
+// modified.
+//
+// When one or more queries fail, the results of the successful queries (and
+// partial results of failed queries) are returned along with a QueryErrors
+// error.
+func SearchMany(ctx context.Context, cl *Client, reqs []*SearchRequest, workers int) ([][]Torrent, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	results := make([][]Torrent, len(reqs))
+	// group identical queries
+	var keys []string
+	groups := make(map[string][]int)
+	for i, req := range reqs {
+		key := "#" + strconv.Itoa(i)
+		if req.f == nil && !req.internalOnly && req.since.IsZero() {
+			buf, _ := json.Marshal(newSearchParams(req))
+			key = string(buf)
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+	var errs QueryErrors
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ch := make(chan []int)
+	for i := 0; i < workers && i < len(keys); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for indexes := range ch {
+				torrents, err := searchAll(ctx, cl, reqs[indexes[0]])
+				mu.Lock()
+				for j, i := range indexes {
+					results[i] = torrents
+					if j != 0 {
+						results[i] = append([]Torrent(nil), torrents...)
+					}
+					if err != nil {
+						errs = append(errs, &QueryError{Index: i, Err: err})
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	var ctxErr error
+loop:
+	for i, key := range keys {
+		if cl.nextDelay != 0 && i != 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(cl.nextDelay):
+			}
+		}
+		select {
+		case <-ctx.Done():
+			ctxErr = ctx.Err()
+			break loop
+		case ch <- groups[key]:
+		}
+	}
+	close(ch)
+	wg.Wait()
+	switch {
+	case ctxErr != nil:
+		return results, ctxErr
+	case len(errs) != 0:
+		sort.Slice(errs, func(i, j int) bool {
+			return errs[i].Index < errs[j].Index
+		})
+		return results, errs
+	}
+	return results, nil
+}
+
+// searchAll retrieves all results for a copy of the search request, removing
+// duplicate torrents.
+func searchAll(ctx context.Context, cl *Client, req *SearchRequest) ([]Torrent, error) {
+	r := req.WithPage(req.Page)
+	r.pager = nil
+	torrents, err := r.All(ctx, cl)
+	seen := make(map[int]bool, len(torrents))
+	v := torrents[:0]
+	for _, t := range torrents {
+		if !seen[t.ID] {
+			seen[t.ID] = true
+			v = append(v, t)
+		}
+	}
+	return v, err
+}
@@ -0,0 +1,55 @@
+package tlapi
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// WithSkipInvalid is a TL client option to skip torrents in search responses
+// that fail to decode, instead of failing the whole response. Skipped
+// torrents are recorded in the response's Skipped list.
+func WithSkipInvalid(skip bool) Option {
+	return func(cl *Client) {
+		cl.skipInvalid = skip
+	}
+}
+
+// SkippedTorrent is a torrent skipped when decoding a search response.
+type SkippedTorrent struct {
+	Index int
+	Raw   json.RawMessage
+	Err   error
+}
+
+// Error satisfies the error interface.
+func (t SkippedTorrent) Error() string {
+	return fmt.Sprintf("torrent %d: %v", t.Index, t.Err)
+}
+
+// decodeSkipInvalid decodes a search response, skipping invalid torrents.
+func decodeSkipInvalid(dec *json.Decoder, res *SearchResponse) error {
+	type alias SearchResponse
+	v := struct {
+		*alias
+		TorrentList []json.RawMessage `json:"torrentList"`
+	}{
+		alias: (*alias)(res),
+	}
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	res.TorrentList = make([]Torrent, 0, len(v.TorrentList))
+	for i, raw := range v.TorrentList {
+		var t Torrent
+		if err := json.Unmarshal(raw, &t); err != nil {
+			res.Skipped = append(res.Skipped, SkippedTorrent{
+				Index: i,
+				Raw:   raw,
+				Err:   err,
+			})
+			continue
+		}
+		res.TorrentList = append(res.TorrentList, t)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package tlapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Error classes.
+const (
+	ErrorClassNetwork = "network"
+	ErrorClassTimeout = "timeout"
+	ErrorClassHTTP4xx = "http_4xx"
+	ErrorClassHTTP5xx = "http_5xx"
+	ErrorClassSession = "session"
+)
+
+// Stats are client transfer statistics.
+type Stats struct {
+	// Requests is the number of requests sent.
+	Requests int64
+	// Bytes is the number of response body bytes read.
+	Bytes int64
+	// Errors are the request error counts by class (see the ErrorClass
+	// constants).
+	Errors map[string]int64
+	// Latency is the cumulative time to response headers.
+	Latency time.Duration
+}
+
+// AvgLatency returns the average time to response headers.
+func (s Stats) AvgLatency() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.Latency / time.Duration(s.Requests)
+}
+
+// Stats returns the client's cumulative transfer statistics.
+func (cl *Client) Stats() Stats {
+	cl.stats.mu.Lock()
+	defer cl.stats.mu.Unlock()
+	s := Stats{
+		Requests: cl.stats.requests,
+		Bytes:    atomic.LoadInt64(&cl.stats.bytes),
+		Errors:   make(map[string]int64, len(cl.stats.errors)),
+		Latency:  cl.stats.latency,
+	}
+	for class, n := range cl.stats.errors {
+		s.Errors[class] = n
+	}
+	return s
+}
+
+// clientStats are tracked client statistics.
+type clientStats struct {
+	mu       sync.Mutex
+	requests int64
+	bytes    int64
+	errors   map[string]int64
+	latency  time.Duration
+}
+
+// track tracks a request's response or error.
+func (s *clientStats) track(d time.Duration, req *http.Request, res *http.Response, err error) {
+	class := ""
+	var nerr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &nerr) && nerr.Timeout():
+		class = ErrorClassTimeout
+	case err != nil:
+		class = ErrorClassNetwork
+	case res.StatusCode >= 500:
+		class = ErrorClassHTTP5xx
+	case res.StatusCode >= 400:
+		class = ErrorClassHTTP4xx
+	case isLogin(res.Request.URL) && !isLogin(req.URL):
+		class = ErrorClassSession
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	s.latency += d
+	if class != "" {
+		if s.errors == nil {
+			s.errors = make(map[string]int64)
+		}
+		s.errors[class]++
+	}
+	if res != nil {
+		res.Body = &countingBody{ReadCloser: res.Body, n: &s.bytes}
+	}
+}
+
+// countingBody counts the bytes read from a response body.
+type countingBody struct {
+	io.ReadCloser
+	n *int64
+}
+
+// Read satisfies the io.Reader interface.
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(b.n, int64(n))
+	return n, err
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/moistari/tlapi"
+)
+
+// Checkpoint is a category archive checkpoint.
+type Checkpoint struct {
+	// Page is the last archived page.
+	Page int `json:"page"`
+	// Done is true when the last page of the category has been archived.
+	Done bool `json:"done,omitempty"`
+	// Time is when the checkpoint was made.
+	Time time.Time `json:"time"`
+}
+
+// ArchiveOptions are category archive options.
+type ArchiveOptions struct {
+	// Budget is the request budget that each page fetch waits for, in
+	// addition to any budget or limiter used by the client.
+	Budget *tlapi.Budget
+	// Delay is the minimum delay between page fetches (default 5 seconds).
+	Delay time.Duration
+	// Progress, when not nil, is called after each archived page.
+	Progress func(ArchiveProgress)
+}
+
+// ArchiveProgress is category archive progress.
+type ArchiveProgress struct {
+	Category int
+	// Page is the archived page.
+	Page int
+	// Pages is the category's total number of pages.
+	Pages int
+	// Torrents is the number of torrents on the archived page.
+	Torrents int
+	// NumFound is the category's total number of torrents.
+	NumFound int
+}
+
+// Archive walks all pages of the categories, oldest first, putting the
+// torrents and a checkpoint for each page in the store. Archiving resumes
+// from the categories' checkpoints, so it can be stopped (for example, by
+// canceling the context) and restarted over days. Categories that were
+// completely archived are resumed from their last page, picking up torrents
+// added since.
+func (s *Store) Archive(ctx context.Context, cl *tlapi.Client, categories []int, opts ArchiveOptions) error {
+	if opts.Delay == 0 {
+		opts.Delay = 5 * time.Second
+	}
+	var last time.Time
+	for _, category := range categories {
+		page := 1
+		if c, ok := s.Checkpoint(category); ok {
+			page = c.Page + 1
+			if c.Done {
+				page = c.Page
+			}
+		}
+		for {
+			if err := wait(ctx, opts.Budget, time.Until(last.Add(opts.Delay))); err != nil {
+				return fmt.Errorf("category %d: %w", category, err)
+			}
+			last = time.Now()
+			res, err := tlapi.Search().
+				WithCategories(category).
+				WithOrderBy(tlapi.OrderByAdded).
+				WithOrder(tlapi.OrderAsc).
+				WithPage(page).
+				Do(ctx, cl)
+			if err != nil {
+				return fmt.Errorf("category %d: %w", category, err)
+			}
+			for _, t := range res.TorrentList {
+				if err := s.PutTorrent(t); err != nil {
+					return fmt.Errorf("category %d: %w", category, err)
+				}
+			}
+			pages := 1
+			if res.PerPage != 0 {
+				pages = (res.NumFound + res.PerPage - 1) / res.PerPage
+			}
+			done := len(res.TorrentList) == 0 || page >= pages
+			if len(res.TorrentList) == 0 && page > 1 {
+				// nothing past the previous page
+				page--
+			}
+			if err := s.PutCheckpoint(category, Checkpoint{Page: page, Done: done, Time: time.Now()}); err != nil {
+				return fmt.Errorf("category %d: %w", category, err)
+			}
+			if opts.Progress != nil {
+				opts.Progress(ArchiveProgress{
+					Category: category,
+					Page:     page,
+					Pages:    pages,
+					Torrents: len(res.TorrentList),
+					NumFound: res.NumFound,
+				})
+			}
+			if done {
+				break
+			}
+			page++
+		}
+	}
+	return nil
+}
+
+// wait waits for the delay and the budget.
+func wait(ctx context.Context, b *tlapi.Budget, d time.Duration) error {
+	if d > 0 {
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	if b != nil {
+		return b.Wait(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+// Package store is a simple file-backed store for torrent metadata, info
+// hash mappings, download history, search sync watermarks, and archive
+// checkpoints, for building private offline indexes, enforcing download
+// quotas, and polling.
+//
+// Records are appended to a JSON Lines file as they are put, so that a store
+// survives crashes and restarts without rewriting the whole file.
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/moistari/tlapi"
+)
+
+// Store is a file-backed store. A store is safe for concurrent use.
+type Store struct {
+	mu     sync.RWMutex
+	f      *os.File
+	w      *bufio.Writer
+	hashes map[int]tlapi.HashEntry
+	grabs  []tlapi.Grab
+	marks  map[string]tlapi.Watermark
+
+	torrents    map[int]tlapi.Torrent
+	checkpoints map[int]Checkpoint
+}
+
+// record is a store record.
+type record struct {
+	Type string           `json:"type"`
+	Hash *tlapi.HashEntry `json:"hash,omitempty"`
+	Grab *tlapi.Grab      `json:"grab,omitempty"`
+	Key  string           `json:"key,omitempty"`
+	Mark *tlapi.Watermark `json:"mark,omitempty"`
+
+	Torrent    *torrentJSON `json:"torrent,omitempty"`
+	Category   int          `json:"category,omitempty"`
+	Checkpoint *Checkpoint  `json:"checkpoint,omitempty"`
+}
+
+// torrentJSON is the stored json representation of a torrent, using the
+// default encoding (tlapi.Torrent decodes the site's representation).
+type torrentJSON tlapi.Torrent
+
+// Open opens the store at the path, creating it if it does not exist.
+func Open(path string) (*Store, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{
+		f:      f,
+		hashes: make(map[int]tlapi.HashEntry),
+		marks:  make(map[string]tlapi.Watermark),
+
+		torrents:    make(map[int]tlapi.Torrent),
+		checkpoints: make(map[int]Checkpoint),
+	}
+	if err := s.load(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("store %s: %w", path, err)
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return nil, err
+	}
+	s.w = bufio.NewWriter(f)
+	return s, nil
+}
+
+// load loads the records from the file. A malformed final record (a line
+// torn by a crash while appending) is truncated from the file.
+func (s *Store) load(f *os.File) error {
+	r := bufio.NewReader(f)
+	var off int64
+	for line := 1; ; line++ {
+		buf, err := r.ReadBytes('\n')
+		switch {
+		case err != nil && !errors.Is(err, io.EOF):
+			return err
+		case len(buf) == 0:
+			return nil
+		}
+		var rec record
+		if len(bytes.TrimSpace(buf)) != 0 {
+			if err := json.Unmarshal(buf, &rec); err != nil {
+				if _, perr := r.Peek(1); perr == nil {
+					return fmt.Errorf("record %d: %w", line, err)
+				}
+				return f.Truncate(off)
+			}
+		}
+		if buf[len(buf)-1] != '\n' {
+			// complete final record missing its newline
+			if _, err := f.WriteAt([]byte{'\n'}, off+int64(len(buf))); err != nil {
+				return err
+			}
+		}
+		s.apply(rec)
+		off += int64(len(buf))
+	}
+}
+
+// apply applies the record to the store.
+func (s *Store) apply(rec record) {
+	switch rec.Type {
+	case "hash":
+		if rec.Hash != nil {
+			s.hashes[rec.Hash.ID] = *rec.Hash
+		}
+	case "grab":
+		if rec.Grab != nil {
+			s.grabs = append(s.grabs, *rec.Grab)
+		}
+	case "watermark":
+		if rec.Mark != nil {
+			s.marks[rec.Key] = *rec.Mark
+		}
+	case "torrent":
+		if rec.Torrent != nil {
+			s.torrents[rec.Torrent.ID] = tlapi.Torrent(*rec.Torrent)
+		}
+	case "checkpoint":
+		if rec.Checkpoint != nil {
+			s.checkpoints[rec.Category] = *rec.Checkpoint
+		}
+	}
+}
+
+// put appends the record to the store file and applies it.
+func (s *Store) put(rec record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return errors.New("store closed")
+	}
+	buf, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := s.w.Write(append(buf, '\n')); err != nil {
+		return err
+	}
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	s.apply(rec)
+	return nil
+}
+
+// PutHash puts the hash entry in the store.
+func (s *Store) PutHash(e tlapi.HashEntry) error {
+	return s.put(record{Type: "hash", Hash: &e})
+}
+
+// Hash returns the hash entry for the torrent id.
+func (s *Store) Hash(id int) (tlapi.HashEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.hashes[id]
+	return e, ok
+}
+
+// Hashes returns the hash entries in the store.
+func (s *Store) Hashes() []tlapi.HashEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v := make([]tlapi.HashEntry, 0, len(s.hashes))
+	for _, e := range s.hashes {
+		v = append(v, e)
+	}
+	return v
+}
+
+// HashIndex returns a hash index built from the hash entries in the store.
+func (s *Store) HashIndex() *tlapi.HashIndex {
+	return tlapi.NewHashIndex(s.Hashes()...)
+}
+
+// PutGrab puts the grab in the store.
+func (s *Store) PutGrab(g tlapi.Grab) error {
+	return s.put(record{Type: "grab", Grab: &g})
+}
+
+// Grabs returns the grabs in the store made after since.
+func (s *Store) Grabs(since time.Time) []tlapi.Grab {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var v []tlapi.Grab
+	for _, g := range s.grabs {
+		if g.Time.After(since) {
+			v = append(v, g)
+		}
+	}
+	return v
+}
+
+// PutWatermark puts the search sync watermark for the key in the store.
+func (s *Store) PutWatermark(key string, w tlapi.Watermark) error {
+	return s.put(record{Type: "watermark", Key: key, Mark: &w})
+}
+
+// Watermark returns the search sync watermark for the key.
+func (s *Store) Watermark(key string) (tlapi.Watermark, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w, ok := s.marks[key]
+	return w, ok
+}
+
+// PutTorrent puts the torrent metadata in the store.
+func (s *Store) PutTorrent(t tlapi.Torrent) error {
+	v := torrentJSON(t)
+	return s.put(record{Type: "torrent", Torrent: &v})
+}
+
+// Torrent returns the torrent metadata for the torrent id.
+func (s *Store) Torrent(id int) (tlapi.Torrent, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.torrents[id]
+	return t, ok
+}
+
+// Torrents returns the torrent metadata in the store, ordered by id.
+func (s *Store) Torrents() []tlapi.Torrent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v := make([]tlapi.Torrent, 0, len(s.torrents))
+	for _, t := range s.torrents {
+		v = append(v, t)
+	}
+	sort.Slice(v, func(i, j int) bool {
+		return v[i].ID < v[j].ID
+	})
+	return v
+}
+
+// PutCheckpoint puts the archive checkpoint for the category in the store.
+func (s *Store) PutCheckpoint(category int, c Checkpoint) error {
+	return s.put(record{Type: "checkpoint", Category: category, Checkpoint: &c})
+}
+
+// Checkpoint returns the archive checkpoint for the category.
+func (s *Store) Checkpoint(category int) (Checkpoint, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.checkpoints[category]
+	return c, ok
+}
+
+// Sync commits the store file to stable storage.
+func (s *Store) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return errors.New("store closed")
+	}
+	return s.f.Sync()
+}
+
+// Close closes the store.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.w.Flush()
+	if e := s.f.Close(); err == nil {
+		err = e
+	}
+	s.f = nil
+	return err
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moistari/tlapi"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.jsonl")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for _, e := range []tlapi.HashEntry{
+		{ID: 1, InfoHash: "aa", Size: 10},
+		{ID: 2, InfoHash: "bb", Size: 20},
+		{ID: 1, InfoHash: "cc", Size: 30},
+	} {
+		if err := s.PutHash(e); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	now := time.Now()
+	for _, g := range []tlapi.Grab{
+		{ID: 1, Size: 10, Time: now.Add(-48 * time.Hour)},
+		{ID: 2, Size: 20, Time: now},
+	} {
+		if err := s.PutGrab(g); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	if err := s.PutWatermark("q", tlapi.Watermark{Time: now.Truncate(time.Second), IDs: []int{2}}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s, err = Open(path); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer s.Close()
+	if n := len(s.Hashes()); n != 2 {
+		t.Errorf("expected 2 entries, got: %d", n)
+	}
+	if e, ok := s.Hash(1); !ok || e.InfoHash != "cc" {
+		t.Errorf("expected entry 1 with hash cc, got: %+v %t", e, ok)
+	}
+	if w, ok := s.Watermark("q"); !ok || !w.Time.Equal(now.Truncate(time.Second)) || len(w.IDs) != 1 {
+		t.Errorf("expected watermark q, got: %+v %t", w, ok)
+	}
+	if v := s.Grabs(now.Add(-24 * time.Hour)); len(v) != 1 || v[0].ID != 2 {
+		t.Errorf("expected grab 2, got: %+v", v)
+	}
+}
+
+func TestStoreTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.jsonl")
+	data := `{"type":"hash","hash":{"id":1,"infoHash":"aa","size":10}}` + "\n" +
+		`{"type":"hash","hash":{"id":2,"infoHash":"bb","size":20}}` + "\n" +
+		`{"type":"hash","hash":{"id":3,"inf`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, ok := s.Hash(2); !ok {
+		t.Errorf("expected hash 2")
+	}
+	if _, ok := s.Hash(3); ok {
+		t.Errorf("expected no hash 3")
+	}
+	if err := s.PutHash(tlapi.HashEntry{ID: 4, InfoHash: "dd", Size: 40}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s, err = Open(path); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer s.Close()
+	if _, ok := s.Hash(4); !ok {
+		t.Errorf("expected hash 4")
+	}
+	// malformed records before the end are errors
+	if err := os.WriteFile(path, []byte("{bad\n"+data), 0o644); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, err := Open(path); err == nil || !strings.Contains(err.Error(), "record 1") {
+		t.Errorf("expected record 1 error, got: %v", err)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	var n int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := strings.LastIndex(r.URL.Path, "/page/")
+		page, _ := strconv.Atoi(r.URL.Path[i+len("/page/"):])
+		var v []string
+		for id := (page-1)*2 + 1; id <= n && id <= page*2; id++ {
+			v = append(v, fmt.Sprintf(`{"fid":"%d","categoryID":1}`, id))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"numFound":%d,"perPage":2,"torrentList":[%s]}`, n, strings.Join(v, ","))
+	}))
+	defer srv.Close()
+	cl := tlapi.New(tlapi.WithBaseURL(srv.URL), tlapi.WithCreds("a", "b", "c"))
+	path := filepath.Join(t.TempDir(), "store.jsonl")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer s.Close()
+	var pages []int
+	opts := ArchiveOptions{
+		Delay: time.Millisecond,
+		Progress: func(p ArchiveProgress) {
+			pages = append(pages, p.Page)
+		},
+	}
+	n = 5
+	if err := s.Archive(context.Background(), cl, []int{1}, opts); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(pages) != 3 || len(s.Torrents()) != 5 {
+		t.Errorf("expected 3 pages and 5 torrents, got: %v %d", pages, len(s.Torrents()))
+	}
+	if c, ok := s.Checkpoint(1); !ok || c.Page != 3 || !c.Done {
+		t.Errorf("expected done checkpoint at page 3, got: %+v %t", c, ok)
+	}
+	// resumes from the last page
+	pages, n = nil, 7
+	if err := s.Archive(context.Background(), cl, []int{1}, opts); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(pages) != 2 || pages[0] != 3 || len(s.Torrents()) != 7 {
+		t.Errorf("expected pages 3 and 4 and 7 torrents, got: %v %d", pages, len(s.Torrents()))
+	}
+	if tr, ok := s.Torrent(7); !ok || tr.CategoryID != 1 {
+		t.Errorf("expected torrent 7, got: %+v %t", tr, ok)
+	}
+}
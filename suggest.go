@@ -0,0 +1,66 @@
+package tlapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Suggest returns the site's search suggestions (titles) for the prefix, as
+// used by the browse page's as-you-type completion.
+func (cl *Client) Suggest(ctx context.Context, prefix string) ([]string, error) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return nil, nil
+	}
+	var v suggestions
+	if err := cl.GetJSON(ctx, "/torrents/browse/autocomplete?query="+url.QueryEscape(prefix), &v); err != nil {
+		return nil, fmt.Errorf("suggest %q: %w", prefix, err)
+	}
+	return v, nil
+}
+
+// suggestions are search suggestions, decoded from either a list of strings,
+// a list of objects with a name, value, label or title, or an object with a
+// suggestions list.
+type suggestions []string
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface.
+func (v *suggestions) UnmarshalJSON(buf []byte) error {
+	var obj struct {
+		Suggestions []json.RawMessage `json:"suggestions"`
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(buf, &list); err != nil {
+		if err := json.Unmarshal(buf, &obj); err != nil {
+			return fmt.Errorf("invalid suggestions: %w", err)
+		}
+		list = obj.Suggestions
+	}
+	*v = (*v)[:0]
+	for _, raw := range list {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			*v = append(*v, s)
+			continue
+		}
+		var item struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+			Label string `json:"label"`
+			Title string `json:"title"`
+		}
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return fmt.Errorf("invalid suggestion: %w", err)
+		}
+		for _, s := range []string{item.Name, item.Value, item.Label, item.Title} {
+			if s != "" {
+				*v = append(*v, s)
+				break
+			}
+		}
+	}
+	return nil
+}
@@ -1,9 +1,11 @@
 package tlapi
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -22,12 +24,18 @@ type SearchRequest struct {
 	Order      string
 	Page       int
 
-	res *SearchResponse
-	i   int
-	p   int
-	d   time.Duration
-	err error
-	mu  sync.Mutex
+	pager *Pager[Torrent]
+	d     time.Duration
+	pt    time.Duration
+	pr    int
+	since time.Time
+	f     func(Torrent) bool
+	mu    sync.Mutex
+
+	// internalOnly skips torrents that are not internal releases, with
+	// internal as the internal groups (see WithInternalOnly).
+	internalOnly bool
+	internal     []string
 }
 
 // Search creates a search request.
@@ -35,12 +43,31 @@ func Search(query ...string) *SearchRequest {
 	return &SearchRequest{
 		Query: query,
 		Page:  1,
-		p:     -1,
-		i:     -1,
 		d:     5 * time.Second,
 	}
 }
 
+// clone returns a copy of the search request's filters and iteration
+// settings, without its pager (and lock).
+func (req *SearchRequest) clone() *SearchRequest {
+	return &SearchRequest{
+		Categories:   req.Categories,
+		Facets:       req.Facets,
+		Query:        req.Query,
+		Added:        req.Added,
+		OrderBy:      req.OrderBy,
+		Order:        req.Order,
+		Page:         req.Page,
+		d:            req.d,
+		pt:           req.pt,
+		pr:           req.pr,
+		since:        req.since,
+		f:            req.f,
+		internalOnly: req.internalOnly,
+		internal:     req.internal,
+	}
+}
+
 // WithCategories adds search category filters.
 func (req SearchRequest) WithCategories(categories ...int) *SearchRequest {
 	req.Categories = categories
@@ -70,25 +97,38 @@ func (req SearchRequest) WithFacet(name string, values ...string) *SearchRequest
 	return &req
 }
 
+// WithExactPhrase adds an exact phrase to the search query, quoting it so
+// that only results containing the words in order match.
+func (req *SearchRequest) WithExactPhrase(phrase string) *SearchRequest {
+	r := req.clone()
+	if s := queryPhrase(phrase); s != "" {
+		r.Query = append(append([]string(nil), r.Query...), s)
+	}
+	return r
+}
+
 // WithPage sets the search page filter.
 func (req SearchRequest) WithPage(page int) *SearchRequest {
 	req.Page = page
 	return &req
 }
 
-// WithAdded sets the search added filter.
+// WithAdded sets the search added filter (see Added constants). Invalid
+// values cause Do to return an error.
 func (req SearchRequest) WithAdded(added string) *SearchRequest {
 	req.Added = added
 	return &req
 }
 
 // WithOrderBy sets the search orderBy parameter (see OrderBy constants).
+// Invalid values cause Do to return an error.
 func (req SearchRequest) WithOrderBy(orderBy string) *SearchRequest {
 	req.OrderBy = orderBy
 	return &req
 }
 
-// WithOrder sets the search order parameter (see Order constants).
+// WithOrder sets the search order parameter (see Order constants). Invalid
+// values cause Do to return an error.
 func (req SearchRequest) WithOrder(order string) *SearchRequest {
 	req.Order = order
 	return &req
@@ -100,8 +140,134 @@ func (req SearchRequest) WithNextDelay(d time.Duration) *SearchRequest {
 	return &req
 }
 
-// Do executes the request against the client.
-func (req *SearchRequest) Do(ctx context.Context, cl *Client) (*SearchResponse, error) {
+// WithPageTimeout sets the timeout for each page fetched by Next, so that a
+// slow page fails fast instead of consuming the whole context.
+func (req *SearchRequest) WithPageTimeout(d time.Duration) *SearchRequest {
+	r := req.clone()
+	r.pt = d
+	return r
+}
+
+// WithPageRetries sets the number of times Next retries fetching a page
+// that fails with a transient error (see IsTransient), with exponential
+// backoff starting at 1 second.
+func (req *SearchRequest) WithPageRetries(n int) *SearchRequest {
+	r := req.clone()
+	r.pr = n
+	return r
+}
+
+// Since returns a request for torrents added after t, ordered newest first.
+// Next and All stop paginating at the first torrent added at or before t,
+// making it an efficient primitive for polling.
+func (req *SearchRequest) Since(t time.Time) *SearchRequest {
+	r := req.clone()
+	r.OrderBy, r.Order, r.since = OrderByAdded, OrderDesc, t
+	return r
+}
+
+// WithFilter sets a filter for torrents returned by Next and All. Torrents
+// for which f returns false are skipped.
+func (req *SearchRequest) WithFilter(f func(Torrent) bool) *SearchRequest {
+	r := req.clone()
+	r.f = f
+	return r
+}
+
+// Do executes the request against the client, applying the call options.
+func (req *SearchRequest) Do(ctx context.Context, cl *Client, opts ...CallOption) (*SearchResponse, error) {
+	if len(opts) != 0 {
+		ctx = WithCallOptions(ctx, opts...)
+	}
+	httpReq, err := req.buildRequest(cl)
+	if err != nil {
+		return nil, req.wrapErr(err)
+	}
+	res, err := cl.do(ctx, httpReq)
+	if err != nil {
+		if cl.htmlFallback && useFallback(err) {
+			return req.scrape(ctx, cl, err)
+		}
+		return nil, req.wrapErr(err)
+	}
+	defer res.Body.Close()
+	v := new(SearchResponse)
+	if err := cl.decode(res, v); err != nil {
+		if cl.htmlFallback {
+			return req.scrape(ctx, cl, err)
+		}
+		return nil, req.wrapErr(newRequestError(httpReq, err))
+	}
+	return v, nil
+}
+
+// Stream executes the request against the client, passing each torrent to f
+// as it is decoded, instead of collecting the response's torrent list. The
+// returned search response has an empty torrent list.
+func (req *SearchRequest) Stream(ctx context.Context, cl *Client, f func(Torrent) error) (*SearchResponse, error) {
+	httpReq, err := req.buildRequest(cl)
+	if err != nil {
+		return nil, req.wrapErr(err)
+	}
+	res, err := cl.do(ctx, httpReq)
+	if err != nil {
+		return nil, req.wrapErr(err)
+	}
+	defer res.Body.Close()
+	r := bufio.NewReader(res.Body)
+	if isHTML(res.Header.Get("Content-Type"), r) {
+		return nil, req.wrapErr(newRequestError(httpReq, newHTMLError(res.Request.URL, r)))
+	}
+	v, err := DecodeTorrents(r, f)
+	if err != nil {
+		return nil, req.wrapErr(newRequestError(httpReq, err))
+	}
+	return v, nil
+}
+
+// wrapErr wraps the error with the search page.
+func (req *SearchRequest) wrapErr(err error) error {
+	page := req.Page
+	if page == 0 {
+		page = 1
+	}
+	return fmt.Errorf("search page %d: %w", page, err)
+}
+
+// Validate validates the search request's added, orderBy, and order
+// values, which the site otherwise silently ignores when invalid.
+func (req *SearchRequest) Validate() error {
+	switch {
+	case req.Added != "" && !contains(addeds, req.Added):
+		return fmt.Errorf("invalid added value %q", req.Added)
+	case req.OrderBy != "" && !contains(orderBys, req.OrderBy):
+		return fmt.Errorf("invalid orderBy value %q", req.OrderBy)
+	case req.Order != "" && req.Order != OrderAsc && req.Order != OrderDesc:
+		return fmt.Errorf("invalid order value %q", req.Order)
+	}
+	return nil
+}
+
+// contains determines if v contains s.
+func contains(v []string, s string) bool {
+	for _, t := range v {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
+
+// buildRequest builds the http request for the search request.
+func (req *SearchRequest) buildRequest(cl *Client) (*http.Request, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return http.NewRequest("GET", cl.url("/torrents/browse/list"+req.path()), nil)
+}
+
+// path returns the browse path for the search request.
+func (req *SearchRequest) path() string {
 	var q string
 	if len(req.Categories) != 0 {
 		var v []string
@@ -123,7 +289,7 @@ func (req *SearchRequest) Do(ctx context.Context, cl *Client) (*SearchResponse,
 		q += "/query/" + url.PathEscape(strings.Join(req.Query, " "))
 	}
 	if req.Added != "" {
-		q += "/added/" + req.Added
+		q += "/added/" + escaper.Replace(req.Added)
 	}
 	if req.OrderBy != "" {
 		q += "/orderby/" + req.OrderBy
@@ -134,16 +300,7 @@ func (req *SearchRequest) Do(ctx context.Context, cl *Client) (*SearchResponse,
 	if req.Page != 0 {
 		q += "/page/" + strconv.Itoa(req.Page)
 	}
-	urlstr := "https://www.torrentleech.org/torrents/browse/list" + q
-	httpReq, err := http.NewRequest("GET", urlstr, nil)
-	if err != nil {
-		return nil, err
-	}
-	res := new(SearchResponse)
-	if err := cl.Do(ctx, httpReq, res); err != nil {
-		return nil, err
-	}
-	return res, nil
+	return q
 }
 
 // Next returns true if there are search results available for the request.
@@ -159,30 +316,48 @@ func (req *SearchRequest) Do(ctx context.Context, cl *Client) (*SearchResponse,
 //		/* ... */
 //	}
 func (req *SearchRequest) Next(ctx context.Context, cl *Client) bool {
-	req.mu.Lock()
-	defer req.mu.Unlock()
-	page := req.Page
-	if page == 0 {
-		page = 1
-	}
-	switch {
-	case req.err != nil:
-		return false
-	case req.res != nil:
-		switch {
-		case req.i < len(req.res.TorrentList)-1:
-			req.i++
+	p := req.getPager(cl)
+	for p.Next(ctx) {
+		if req.match(p.Cur()) {
 			return true
-		case (page+req.p)*req.res.PerPage >= req.res.NumFound:
-			return false
 		}
 	}
-	req.p, req.i = req.p+1, 0
-	if req.d != 0 && req.p != 0 {
-		<-time.After(req.d)
+	return false
+}
+
+// match determines if the torrent passes the request's internal check and
+// filter.
+func (req *SearchRequest) match(t Torrent) bool {
+	if req.internalOnly && !t.Internal(req.internal...) {
+		return false
+	}
+	return req.f == nil || req.f(t)
+}
+
+// getPager returns the request's pager, creating it when necessary.
+func (req *SearchRequest) getPager(cl *Client) *Pager[Torrent] {
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	if req.pager == nil {
+		req.pager = NewPager(req.Page, cl.pageDelay(req.d), func(ctx context.Context, page int) ([]Torrent, bool, error) {
+			res, err := req.WithPage(page).Do(ctx, cl)
+			if err != nil {
+				return nil, false, err
+			}
+			torrents, more := res.TorrentList, page*res.PerPage < res.NumFound
+			if !req.since.IsZero() {
+				for i, t := range torrents {
+					if !t.AddedTimestamp.After(req.since) {
+						torrents, more = torrents[:i], false
+						break
+					}
+				}
+			}
+			return torrents, more, nil
+		})
+		req.pager.Timeout, req.pager.Retries = req.pt, req.pr
 	}
-	req.res, req.err = req.WithPage(page+req.p).Do(ctx, cl)
-	return req.err == nil && req.i < len(req.res.TorrentList)
+	return req.pager
 }
 
 // Cur returns the search response cursor's current torrent. Returns the same
@@ -191,8 +366,9 @@ func (req *SearchRequest) Next(ctx context.Context, cl *Client) bool {
 // See Next for an overview of using this method.
 func (req *SearchRequest) Cur() Torrent {
 	req.mu.Lock()
-	defer req.mu.Unlock()
-	return req.res.TorrentList[req.i]
+	p := req.pager
+	req.mu.Unlock()
+	return p.Cur()
 }
 
 // Err returns the last error in the search response.
@@ -200,41 +376,121 @@ func (req *SearchRequest) Cur() Torrent {
 // See Next for an overview of using this method.
 func (req *SearchRequest) Err() error {
 	req.mu.Lock()
-	defer req.mu.Unlock()
-	return req.err
+	p := req.pager
+	req.mu.Unlock()
+	if p == nil {
+		return nil
+	}
+	return p.Err()
 }
 
-// All returns all results for the search request.
+// All returns all results for the search request. When an error occurs,
+// the results collected before the error are returned along with it.
 func (req *SearchRequest) All(ctx context.Context, cl *Client) ([]Torrent, error) {
 	var torrents []Torrent
 	for req.Next(ctx, cl) {
 		torrents = append(torrents, req.Cur())
 	}
-	if err := req.Err(); err != nil {
-		return nil, err
-	}
-	return torrents, nil
+	return torrents, req.Err()
 }
 
 // SearchResponse is a search response.
 type SearchResponse struct {
-	Facets struct {
-		CategoryID FacetID `json:"categoryID,omitempty"`
-		Added      Facet   `json:"added,omitempty"`
-		Name       Facet   `json:"name,omitempty"`
-		Seeders    Facet   `json:"seeders,omitempty"`
-		Size       Facet   `json:"size,omitempty"`
-		Tags       Tags    `json:"tags,omitempty"`
-	} `json:"facets,omitempty"`
-	Facetswoc      map[string]Tags `json:"facetswoc,omitempty"`
-	LastBrowseTime Time            `json:"lastBrowseTime,omitempty"`
-	NumFound       int             `json:"numFound,omitempty"`
-	OrderBy        string          `json:"orderBy,omitempty"`
-	Order          string          `json:"order,omitempty"`
-	Page           int             `json:"page,omitempty"`
-	PerPage        int             `json:"perPage,omitempty"`
-	TorrentList    []Torrent       `json:"torrentList,omitempty"`
-	UserTimeZone   string          `json:"userTimeZone,omitempty"`
+	Facets SearchFacets `json:"facets,omitempty"`
+	// Facetswoc are the facets without the current filter for each facet,
+	// showing what each active filter is hiding.
+	Facetswoc      FacetsWOC `json:"facetswoc,omitempty"`
+	LastBrowseTime Time      `json:"lastBrowseTime,omitempty"`
+	NumFound       int       `json:"numFound,omitempty"`
+	OrderBy        string    `json:"orderBy,omitempty"`
+	Order          string    `json:"order,omitempty"`
+	Page           int       `json:"page,omitempty"`
+	PerPage        int       `json:"perPage,omitempty"`
+	TorrentList    []Torrent `json:"torrentList,omitempty"`
+	UserTimeZone   string    `json:"userTimeZone,omitempty"`
+	// Skipped are the invalid torrents skipped when decoding (see
+	// WithSkipInvalid).
+	Skipped []SkippedTorrent `json:"-"`
+}
+
+// SearchFacets are search response facets.
+type SearchFacets struct {
+	CategoryID FacetID `json:"categoryID,omitempty"`
+	Added      Facet   `json:"added,omitempty"`
+	Name       Facet   `json:"name,omitempty"`
+	Seeders    Facet   `json:"seeders,omitempty"`
+	Size       Facet   `json:"size,omitempty"`
+	Tags       Tags    `json:"tags,omitempty"`
+}
+
+// DecodeTorrents decodes a search response from the reader, passing each
+// torrent in the torrent list to f as it is decoded, reducing peak memory use
+// for large responses. Decoding stops at the first error returned by f. The
+// returned search response has an empty torrent list.
+func DecodeTorrents(r io.Reader, f func(Torrent) error) (*SearchResponse, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	m := make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid key %v", tok)
+		}
+		if key != "torrentList" {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return nil, err
+			}
+			m[key] = raw
+			continue
+		}
+		if err := expectDelim(dec, '['); err != nil {
+			return nil, err
+		}
+		for dec.More() {
+			var torrent Torrent
+			if err := dec.Decode(&torrent); err != nil {
+				return nil, err
+			}
+			if err := f(torrent); err != nil {
+				return nil, err
+			}
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return nil, err
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	res := new(SearchResponse)
+	if err := json.Unmarshal(buf, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// expectDelim reads the next token from the decoder, returning an error if it
+// is not the delimiter.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	switch {
+	case err != nil:
+		return err
+	case tok != delim:
+		return fmt.Errorf("expected %v, got: %v", delim, tok)
+	}
+	return nil
 }
 
 // Facet is a facet.
@@ -270,6 +526,7 @@ type Tags struct {
 // Torrent is a torrent.
 type Torrent struct {
 	AddedTimestamp     time.Time `json:"addedTimestamp,omitempty"`
+	AnimeID            string    `json:"animeID,omitempty"`
 	CategoryID         int       `json:"categoryID,omitempty"`
 	Completed          int       `json:"completed,omitempty"`
 	DownloadMultiplier int       `json:"download_multiplier,omitempty"`
@@ -288,149 +545,175 @@ type Torrent struct {
 	Tags               []string  `json:"tags,omitempty"`
 	TvmazeID           string    `json:"tvmazeID,omitempty"`
 	Uploader           string    `json:"uploader,omitempty"`
+
+	// Nuked is true when the torrent is marked as nuked or retired (see
+	// NukeReason).
+	Nuked      bool   `json:"nuked,omitempty"`
+	NukeReason string `json:"nukeReason,omitempty"`
+}
+
+// HasTag returns true when the torrent has the tag (case-insensitive).
+func (t Torrent) HasTag(tag string) bool {
+	for _, s := range t.Tags {
+		if strings.EqualFold(s, tag) {
+			return true
+		}
+	}
+	return false
 }
 
 // UnmarshalJSON satisfies the json.Unmarshaler interface.
 func (t *Torrent) UnmarshalJSON(buf []byte) error {
-	var m map[string]interface{}
-	if err := json.Unmarshal(buf, &m); err != nil {
+	var v torrentJSON
+	if err := json.Unmarshal(buf, &v); err != nil {
 		return err
 	}
-	torrent := Torrent{}
-	for k, v := range m {
-		switch k {
-		case "addedTimestamp":
-			s, ok := v.(string)
-			if !ok {
-				return fmt.Errorf("invalid addedTimestamp type %T", v)
-			}
-			var err error
-			if torrent.AddedTimestamp, err = time.Parse(timefmt, s); err != nil {
-				return fmt.Errorf("invalid addedTimestamp value %q: %w", s, err)
-			}
-		case "categoryID":
-			f, ok := v.(float64)
-			if !ok {
-				return fmt.Errorf("invalid categoryID type %T", v)
-			}
-			torrent.CategoryID = int(f)
-		case "completed":
-			f, ok := v.(float64)
-			if !ok {
-				return fmt.Errorf("invalid completed type %T", v)
-			}
-			torrent.Completed = int(f)
-		case "download_multiplier":
-			f, ok := v.(float64)
-			if !ok {
-				return fmt.Errorf("invalid download_multiplier type %T", v)
-			}
-			torrent.DownloadMultiplier = int(f)
-		case "fid":
-			s, ok := v.(string)
-			if !ok {
-				return fmt.Errorf("invalid fid type %T", v)
-			}
-			var err error
-			if torrent.ID, err = strconv.Atoi(s); err != nil {
-				return fmt.Errorf("invalid fid value %q: %w", s, err)
-			}
-		case "filename":
-			var ok bool
-			if torrent.Filename, ok = v.(string); !ok {
-				return fmt.Errorf("invalid filename type %T", v)
-			}
-		case "genres":
-			s, ok := v.(string)
-			if !ok {
-				return fmt.Errorf("invalid genres type %T", v)
-			}
-			torrent.Genres = strings.Split(s, ", ")
-		case "igdbID":
-			switch x := v.(type) {
-			case string:
-				torrent.IgdbID = x
-			case float64:
-				torrent.IgdbID = strconv.Itoa(int(x))
-			default:
-				return fmt.Errorf("invalid igdbID type %T", v)
-			}
-		case "imdbID":
-			var ok bool
-			if torrent.ImdbID, ok = v.(string); !ok {
-				return fmt.Errorf("invalid imdbID type %T", v)
-			}
-		case "leechers":
-			f, ok := v.(float64)
-			if !ok {
-				return fmt.Errorf("invalid leechers type %T", v)
-			}
-			torrent.Leechers = int(f)
-		case "name":
-			var ok bool
-			if torrent.Name, ok = v.(string); !ok {
-				return fmt.Errorf("invalid name type %T", v)
-			}
-		case "new":
-			var ok bool
-			if torrent.New, ok = v.(bool); !ok {
-				return fmt.Errorf("invalid new type %T", v)
-			}
-		case "numComments":
-			f, ok := v.(float64)
-			if !ok {
-				return fmt.Errorf("invalid numComments type %T", v)
-			}
-			torrent.NumComments = int(f)
-		case "rating":
-			var ok bool
-			if torrent.Rating, ok = v.(float64); !ok {
-				return fmt.Errorf("invalid rating type %T", v)
-			}
-		case "seeders":
-			f, ok := v.(float64)
-			if !ok {
-				return fmt.Errorf("invalid seeders type %T", v)
-			}
-			torrent.Seeders = int(f)
-		case "size":
-			f, ok := v.(float64)
-			if !ok {
-				return fmt.Errorf("invalid size type %T", v)
-			}
-			torrent.Size = int64(f)
-		case "tags":
-			switch x := v.(type) {
-			case string:
-			case []interface{}:
-				for i, z := range x {
-					s, ok := z.(string)
-					if !ok {
-						return fmt.Errorf("invalid tags value type %T (pos %d)", z, i)
-					}
-					torrent.Tags = append(torrent.Tags, s)
-				}
-			default:
-				return fmt.Errorf("invalid tags type %T", v)
-			}
-		case "tvmazeID":
-			var ok bool
-			if torrent.TvmazeID, ok = v.(string); !ok {
-				return fmt.Errorf("invalid tvmazeID type %T", v)
-			}
-		case "uploader":
-			var ok bool
-			if torrent.Uploader, ok = v.(string); !ok {
-				return fmt.Errorf("invalid uploader type %T", v)
-			}
-		default:
-			return fmt.Errorf("unknown field %q", k)
+	torrent := Torrent{
+		AnimeID:            string(v.AnimeID),
+		CategoryID:         v.CategoryID,
+		Completed:          v.Completed,
+		DownloadMultiplier: v.DownloadMultiplier,
+		Filename:           v.Filename,
+		IgdbID:             string(v.IgdbID),
+		ImdbID:             v.ImdbID,
+		Leechers:           v.Leechers,
+		Name:               v.Name,
+		New:                v.New,
+		NumComments:        v.NumComments,
+		Rating:             v.Rating,
+		Seeders:            v.Seeders,
+		Size:               v.Size,
+		Tags:               v.Tags,
+		TvmazeID:           v.TvmazeID,
+		Uploader:           v.Uploader,
+	}
+	var err error
+	if v.AddedTimestamp != "" {
+		if torrent.AddedTimestamp, err = time.Parse(timefmt, v.AddedTimestamp); err != nil {
+			return fmt.Errorf("invalid addedTimestamp value %q: %w", v.AddedTimestamp, err)
+		}
+	}
+	if v.Fid != "" {
+		if torrent.ID, err = strconv.Atoi(v.Fid); err != nil {
+			return fmt.Errorf("invalid fid value %q: %w", v.Fid, err)
 		}
 	}
+	if v.Genres != "" {
+		torrent.Genres = strings.Split(v.Genres, ", ")
+	}
+	torrent.Nuked, torrent.NukeReason = nukeStatus(torrent.Tags)
 	*t = torrent
 	return nil
 }
 
+// torrentJSON is the json representation of a torrent.
+type torrentJSON struct {
+	AddedTimestamp     string     `json:"addedTimestamp"`
+	AnimeID            jsonString `json:"animeID"`
+	CategoryID         int        `json:"categoryID"`
+	Completed          int        `json:"completed"`
+	DownloadMultiplier int        `json:"download_multiplier"`
+	Fid                string     `json:"fid"`
+	Filename           string     `json:"filename"`
+	Genres             string     `json:"genres"`
+	IgdbID             jsonString `json:"igdbID"`
+	ImdbID             string     `json:"imdbID"`
+	Leechers           int        `json:"leechers"`
+	Name               string     `json:"name"`
+	New                bool       `json:"new"`
+	NumComments        int        `json:"numComments"`
+	Rating             float64    `json:"rating"`
+	Seeders            int        `json:"seeders"`
+	Size               int64      `json:"size"`
+	Tags               jsonTags   `json:"tags"`
+	TvmazeID           string     `json:"tvmazeID"`
+	Uploader           string     `json:"uploader"`
+}
+
+// jsonString is a string value that may be encoded as a json string or
+// number.
+type jsonString string
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface.
+func (s *jsonString) UnmarshalJSON(buf []byte) error {
+	switch {
+	case string(buf) == "null":
+		return nil
+	case len(buf) != 0 && buf[0] == '"':
+		var v string
+		if err := json.Unmarshal(buf, &v); err != nil {
+			return err
+		}
+		*s = jsonString(v)
+		return nil
+	}
+	var v json.Number
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return fmt.Errorf("invalid string or number value %s", buf)
+	}
+	*s = jsonString(v)
+	return nil
+}
+
+// jsonTags are tags that may be encoded as a json array of strings, or an
+// empty string when there are no tags.
+type jsonTags []string
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface.
+func (tags *jsonTags) UnmarshalJSON(buf []byte) error {
+	if len(buf) == 0 || buf[0] != '[' {
+		var s string
+		if err := json.Unmarshal(buf, &s); err != nil && string(buf) != "null" {
+			return fmt.Errorf("invalid tags value %s", buf)
+		}
+		return nil
+	}
+	return json.Unmarshal(buf, (*[]string)(tags))
+}
+
+// torrentFields are the known torrent json fields.
+var torrentFields = map[string]bool{
+	"addedTimestamp":      true,
+	"animeID":             true,
+	"categoryID":          true,
+	"completed":           true,
+	"download_multiplier": true,
+	"fid":                 true,
+	"filename":            true,
+	"genres":              true,
+	"igdbID":              true,
+	"imdbID":              true,
+	"leechers":            true,
+	"name":                true,
+	"new":                 true,
+	"numComments":         true,
+	"rating":              true,
+	"seeders":             true,
+	"size":                true,
+	"tags":                true,
+	"tvmazeID":            true,
+	"uploader":            true,
+}
+
+// checkTorrentFields checks that the torrent list in the search response
+// contains only known fields.
+func checkTorrentFields(buf []byte) error {
+	var res struct {
+		TorrentList []map[string]json.RawMessage `json:"torrentList"`
+	}
+	if err := json.Unmarshal(buf, &res); err != nil {
+		return err
+	}
+	for i, m := range res.TorrentList {
+		for k := range m {
+			if !torrentFields[k] {
+				return fmt.Errorf("unknown field %q (torrent %d)", k, i)
+			}
+		}
+	}
+	return nil
+}
+
 // Time is a time value.
 type Time struct {
 	time.Time
@@ -478,8 +761,42 @@ const (
 	OrderByCompleted   = "completed"
 	OrderBySeeders     = "seeders"
 	OrderByLeechers    = "leechers"
+	OrderByRating      = "rating"
 )
 
+// orderBys are the valid orderBy values.
+var orderBys = []string{
+	OrderByNameSort,
+	OrderByAdded,
+	OrderByNumComments,
+	OrderBySize,
+	OrderByCompleted,
+	OrderBySeeders,
+	OrderByLeechers,
+	OrderByRating,
+}
+
+// Added values, the keys of the site's added facet (see
+// SearchFacets.Added).
+const (
+	Added24Hours = RangeLast24Hours
+	Added48Hours = RangeLast48Hours
+	Added72Hours = RangeLast72Hours
+	AddedWeek    = RangeLastWeek
+	Added2Weeks  = RangeLast2Weeks
+	AddedMonth   = RangeLastMonth
+)
+
+// addeds are the valid added values.
+var addeds = []string{
+	Added24Hours,
+	Added48Hours,
+	Added72Hours,
+	AddedWeek,
+	Added2Weeks,
+	AddedMonth,
+}
+
 // Facet filter values.
 const (
 	RangeLast2Weeks  = "[NOW/HOUR-14DAYS TO NOW/HOUR+1HOUR]"
@@ -498,6 +815,36 @@ const (
 	Size750MBto1_5GB = "[786432000 TO 1610612736]"
 )
 
+// Tag values.
+const (
+	TagFreeleech   = "FREELEECH"
+	Tag2160p       = "2160p"
+	Tag1080p       = "1080p"
+	Tag720p        = "720p"
+	TagHDR         = "HDR"
+	TagDolbyVision = "DV"
+	TagRemux       = "REMUX"
+	TagInternal    = "INTERNAL"
+	TagScene       = "SCENE"
+	TagAtmos       = "ATMOS"
+	TagX265        = "x265"
+)
+
+// KnownTags are the known tag values, followed by any generated tags.
+var KnownTags = append([]string{
+	TagFreeleech,
+	Tag2160p,
+	Tag1080p,
+	Tag720p,
+	TagHDR,
+	TagDolbyVision,
+	TagRemux,
+	TagInternal,
+	TagScene,
+	TagAtmos,
+	TagX265,
+}, genTags...)
+
 // Categories.
 const (
 	CategoryMoviesCam               = 8
@@ -557,5 +904,8 @@ var escaper = strings.NewReplacer(
 	"]", "%255D",
 )
 
+// baseURL is the site's default base url.
+const baseURL = "https://www.torrentleech.org"
+
 // timefmt is the time format used for parsing and displaying time values.
 const timefmt = "2006-01-02 15:04:05"
@@ -1,13 +1,34 @@
 package tlapi
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 func TestSearch(t *testing.T) {
 	cl := buildClient(t)
+	WithStrictDecoding(true)(cl)
 	req := Search().
 		WithCategories(CategoryForeignMovies).
 		WithFacets(FacetSize, Size15GBPlus).
@@ -26,12 +47,13 @@ func TestSearch(t *testing.T) {
 
 func TestNext(t *testing.T) {
 	cl := buildClient(t)
+	WithStrictDecoding(true)(cl)
 	req := Search("framestor", "2019")
 	var torrents []Torrent
 	for req.Next(context.Background(), cl) {
 		torrent := req.Cur()
 		torrents = append(torrents, torrent)
-		t.Logf("%d %03d: %07d %q %d", req.p, req.i, torrent.ID, torrent.Name, torrent.Size)
+		t.Logf("%d %03d: %07d %q %d", req.pager.p, req.pager.i, torrent.ID, torrent.Name, torrent.Size)
 	}
 	if err := req.Err(); err != nil {
 		t.Fatalf("expected no error, got: %v", err)
@@ -43,6 +65,7 @@ func TestNext(t *testing.T) {
 
 func TestTorrent(t *testing.T) {
 	cl := buildClient(t)
+	WithStrictDecoding(true)(cl)
 	buf, err := cl.Torrent(context.Background(), 1319660)
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
@@ -51,3 +74,2267 @@ func TestTorrent(t *testing.T) {
 		t.Errorf("expected buf to contain torrent name")
 	}
 }
+
+func TestCategories(t *testing.T) {
+	seen := make(map[int]bool)
+	for _, c := range Categories() {
+		if seen[c.ID] {
+			t.Errorf("duplicate category %d", c.ID)
+		}
+		seen[c.ID] = true
+		if c.Name == "" || c.Group == "" || c.Kind == "" {
+			t.Errorf("category %d missing metadata: %+v", c.ID, c)
+		}
+	}
+	if s, exp := CategoryName(CategoryMovies4k), "Movies :: 4K"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	if _, ok := CategoryByID(-1); ok {
+		t.Errorf("expected unknown category")
+	}
+}
+
+func TestFacetRanges(t *testing.T) {
+	f := Facet{
+		Items: map[string]Item{
+			Size15GBPlus:     {Count: 1},
+			Size0to750MB:     {Count: 2},
+			Size4_5GBto15GB:  {Count: 3},
+			Size750MBto1_5GB: {Count: 4},
+			Size1_5GBto4_5GB: {Count: 5},
+			"bogus":          {Count: 6},
+		},
+	}
+	exp := []string{Size0to750MB, Size750MBto1_5GB, Size1_5GBto4_5GB, Size4_5GBto15GB, Size15GBPlus, "bogus"}
+	v := f.Ranges()
+	if len(v) != len(exp) {
+		t.Fatalf("expected %d items, got: %d", len(exp), len(v))
+	}
+	for i, item := range v {
+		if item.Key != exp[i] {
+			t.Errorf("item %d expected %q, got: %q", i, exp[i], item.Key)
+		}
+	}
+	lower, upper, ok := ParseRange(RangeLast24Hours)
+	if !ok || lower != -24*60*60 || upper != 60 {
+		t.Errorf("expected -86400 60 true, got: %d %d %t", lower, upper, ok)
+	}
+}
+
+func TestDecodeTorrents(t *testing.T) {
+	const body = `{"numFound":2,"perPage":50,"torrentList":[{"fid":"1","name":"a","size":10,"tags":""},{"fid":"2","name":"b","size":20,"tags":["REMUX"]}],"page":1}`
+	var torrents []Torrent
+	res, err := DecodeTorrents(strings.NewReader(body), func(torrent Torrent) error {
+		torrents = append(torrents, torrent)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if res.NumFound != 2 || res.PerPage != 50 || res.Page != 1 {
+		t.Errorf("expected numFound/perPage/page 2/50/1, got: %d/%d/%d", res.NumFound, res.PerPage, res.Page)
+	}
+	if len(torrents) != 2 || torrents[0].ID != 1 || torrents[1].Name != "b" || !torrents[1].HasTag(TagRemux) {
+		t.Errorf("unexpected torrents: %+v", torrents)
+	}
+}
+
+func TestNewJar(t *testing.T) {
+	jar, err := NewJar(
+		"https://www.torrentleech.org",
+		&http.Cookie{Name: "cf_clearance", Value: "a"},
+		&http.Cookie{Name: "tluid", Value: "1"},
+		&http.Cookie{Name: "cf_clearance", Value: "b"},
+	)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for _, urlstr := range []string{"https://www.torrentleech.org/", "https://torrentleech.org/"} {
+		u, _ := url.Parse(urlstr)
+		cookies := jar.Cookies(u)
+		if len(cookies) != 2 {
+			t.Fatalf("expected 2 cookies for %s, got: %v", urlstr, cookies)
+		}
+		for _, c := range cookies {
+			if c.Name == "cf_clearance" && c.Value != "b" {
+				t.Errorf("expected cf_clearance b, got: %s", c.Value)
+			}
+		}
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 test vector (SHA-1), secret "12345678901234567890"
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	for _, test := range []struct {
+		unix int64
+		exp  string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{2000000000, "279037"},
+	} {
+		code, err := TOTP(secret, time.Unix(test.unix, 0))
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if code != test.exp {
+			t.Errorf("%d expected %s, got: %s", test.unix, test.exp, code)
+		}
+	}
+}
+
+func TestBudget(t *testing.T) {
+	b := NewBudget(2, 50*time.Millisecond)
+	if !b.Take() || !b.Take() {
+		t.Fatalf("expected budget to allow 2 requests")
+	}
+	if b.Take() {
+		t.Errorf("expected budget to be exhausted")
+	}
+	if n := b.Remaining(); n != 0 {
+		t.Errorf("expected 0 remaining, got: %d", n)
+	}
+	start := time.Now()
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("expected wait of at least 40ms, got: %v", d)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	q := Query().Must("1080p").Phrase("Planet Earth II").Any("x264", "h.264").Not("x265", "HDR10+")
+	if s, exp := q.String(), `1080p "Planet Earth II" (x264 OR h.264) -x265 -HDR10\+`; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestSavedSearches(t *testing.T) {
+	searches := []SavedSearch{
+		{Name: "4k remux", Request: Search().WithCategories(CategoryMovies4k).WithFacet(FacetTags, TagRemux)},
+		{Name: "my show", Request: Search("the", "show").WithOrderBy(OrderByAdded).WithOrder(OrderDesc)},
+	}
+	var buf bytes.Buffer
+	if err := SaveSearches(&buf, searches); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	v, err := LoadSearches(&buf)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(v) != 2 || v[0].Name != "4k remux" || v[0].Request.Facets[FacetTags] != TagRemux || strings.Join(v[1].Request.Query, " ") != "the show" {
+		t.Errorf("unexpected saved searches: %+v", v)
+	}
+}
+
+func TestParseRelease(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		exp  Release
+	}{
+		{
+			"Fight.Club.1999.1080p.BluRay.REMUX.AVC.DTS-HD.MA5.1-HDH",
+			Release{Title: "Fight Club", Year: 1999, Resolution: Resolution1080p, Source: SourceBluRay, Codec: "x264", Remux: true, Group: "HDH"},
+		},
+		{
+			"The.Show.S02E05.720p.WEB-DL.x265-GROUP",
+			Release{Title: "The Show", Season: 2, Episode: 5, Resolution: Resolution720p, Source: SourceWEBDL, Codec: "x265", Group: "GROUP"},
+		},
+		{
+			"Blade.Runner.2049.2017.2160p.UHD.BluRay.HDR.HEVC-FraMeSToR",
+			Release{Title: "Blade Runner 2049", Year: 2017, Resolution: Resolution2160p, Source: SourceBluRay, Codec: "x265", HDR: "HDR", Group: "FraMeSToR"},
+		},
+		{
+			"Charlottes.Web.2006.DVDRip.XviD-GRP",
+			Release{Title: "Charlottes Web", Year: 2006, Source: SourceDVD, Codec: "XviD", Group: "GRP"},
+		},
+	} {
+		if r := ParseRelease(test.name); r != test.exp {
+			t.Errorf("%s expected %+v, got: %+v", test.name, test.exp, r)
+		}
+	}
+}
+
+func TestParseMetainfo(t *testing.T) {
+	info := "d6:lengthi1024e4:name8:test.bin12:piece lengthi16384e6:pieces20:aaaaaaaaaaaaaaaaaaaa7:privatei1ee"
+	buf := []byte("d8:announce42:https://tracker.example/a/passkey/announce4:info" + info + "e")
+	m, err := ParseMetainfo(buf)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	sum := sha1.Sum([]byte(info))
+	if exp := hex.EncodeToString(sum[:]); m.InfoHash != exp {
+		t.Errorf("expected info hash %s, got: %s", exp, m.InfoHash)
+	}
+	if m.Name != "test.bin" || m.Length != 1024 || m.PieceLength != 16384 || m.Pieces != 1 || !m.Private {
+		t.Errorf("unexpected metainfo: %+v", m)
+	}
+	idx := NewHashIndex()
+	if _, err := idx.AddMetainfo(1, buf); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if e, ok := idx.Lookup(strings.ToUpper(m.InfoHash)); !ok || e.ID != 1 || e.Size != 1024 {
+		t.Errorf("unexpected lookup: %+v %t", e, ok)
+	}
+}
+
+func TestDownloaderQuota(t *testing.T) {
+	now := time.Now()
+	d := &Downloader{
+		Daily:  Quota{Snatches: 2},
+		Weekly: Quota{Bytes: 100},
+		grabs: []Grab{
+			{ID: 1, Size: 40, Time: now.Add(-2 * time.Hour)},
+			{ID: 2, Size: 40, Time: now.Add(-48 * time.Hour)},
+		},
+	}
+	if err := d.Allow(Torrent{ID: 3, Size: 10}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	err := d.Allow(Torrent{ID: 3, Size: 30})
+	var qerr *QuotaError
+	if !errors.As(err, &qerr) || !errors.Is(err, ErrQuotaExceeded) || qerr.Period != QuotaWeekly {
+		t.Errorf("expected weekly quota error, got: %v", err)
+	}
+	d.grabs = append(d.grabs, Grab{ID: 3, Size: 1, Time: now})
+	if err := d.Allow(Torrent{ID: 4}); !errors.As(err, &qerr) || qerr.Period != QuotaDaily {
+		t.Errorf("expected daily quota error, got: %v", err)
+	}
+	daily, weekly := d.Usage()
+	if daily.Snatches != 2 || weekly.Bytes != 81 {
+		t.Errorf("expected usage 2 snatches / 81 bytes, got: %+v %+v", daily, weekly)
+	}
+}
+
+func TestDownloaderConcurrent(t *testing.T) {
+	started, release := make(chan bool, 1), make(chan bool)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/download/1/a" {
+			started <- true
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/x-bittorrent")
+		fmt.Fprint(w, "d4:infod6:lengthi10e4:name8:test.bin12:piece lengthi16384e6:pieces20:aaaaaaaaaaaaaaaaaaaaee")
+	}))
+	defer srv.Close()
+	var logged []string
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"), WithLogf(func(s string, v ...interface{}) {
+		logged = append(logged, fmt.Sprintf(s, v...))
+	}))
+	d := &Downloader{
+		Client: cl,
+		Store:  failGrabStore{},
+		Daily:  Quota{Snatches: 1},
+	}
+	errc := make(chan error, 1)
+	go func() {
+		_, err := d.Download(context.Background(), Torrent{ID: 1})
+		errc <- err
+	}()
+	<-started
+	// the grab in progress is reserved, and does not block usage
+	if daily, _ := d.Usage(); daily.Snatches != 1 {
+		t.Errorf("expected 1 snatch, got: %+v", daily)
+	}
+	if _, err := d.Download(context.Background(), Torrent{ID: 2}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected quota error, got: %v", err)
+	}
+	close(release)
+	if err := <-errc; err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	// unstored grabs are logged and still counted
+	if len(logged) != 1 || !strings.Contains(logged[0], "unable to store grab") {
+		t.Errorf("expected store error logged, got: %q", logged)
+	}
+	if daily, _ := d.Usage(); daily.Snatches != 1 || daily.Bytes != 10 {
+		t.Errorf("expected 1 snatch of 10 bytes, got: %+v", daily)
+	}
+	if err := d.Allow(Torrent{ID: 2}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected quota error, got: %v", err)
+	}
+}
+
+// failGrabStore is a grab store that fails to store grabs.
+type failGrabStore struct{}
+
+func (failGrabStore) PutGrab(Grab) error {
+	return errors.New("read-only")
+}
+
+func (failGrabStore) Grabs(time.Time) []Grab {
+	return nil
+}
+
+func TestRatioGuard(t *testing.T) {
+	g := &RatioGuard{
+		Source: AccountSourceFunc(func(context.Context) (Account, error) {
+			return Account{Uploaded: 150, Downloaded: 100}, nil
+		}),
+		MinRatio:  1,
+		MinBuffer: 20,
+	}
+	ctx := context.Background()
+	if err := g.Check(ctx, Torrent{ID: 1, Size: 30}); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+	if err := g.Check(ctx, Torrent{ID: 2, Size: 40}); !errors.Is(err, ErrRatioGuard) {
+		t.Errorf("expected ratio guard error, got: %v", err)
+	}
+	if err := g.Check(ctx, Torrent{ID: 3, Size: 40, Tags: []string{TagFreeleech}}); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+	g.Downgrade = true
+	if ok, err := g.FreeleechOnly(ctx); err != nil || ok {
+		t.Errorf("expected not freeleech only, got: %t %v", ok, err)
+	}
+	if err := g.Check(ctx, Torrent{ID: 4, Size: 40}); !errors.Is(err, ErrRatioGuard) {
+		t.Errorf("expected ratio guard error, got: %v", err)
+	}
+	g.MinBuffer = 60
+	if ok, err := g.FreeleechOnly(ctx); err != nil || !ok {
+		t.Errorf("expected freeleech only, got: %t %v", ok, err)
+	}
+	if err := g.Check(ctx, Torrent{ID: 5, Size: 1}); !errors.Is(err, ErrRatioGuard) {
+		t.Errorf("expected ratio guard error, got: %v", err)
+	}
+}
+
+func TestLibrary(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"The Movie (2019)/The Movie (2019).mkv",
+		"Some Show/Season 02/S02E03.mkv",
+		"Other Show/Other.Show.S01E01.720p.HDTV.x264-GRP.mkv",
+		"Other Show/notes.txt",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	l := NewLibrary()
+	if err := l.Scan(dir); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if n := l.Len(); n != 3 {
+		t.Errorf("expected 3 items, got: %d", n)
+	}
+	tests := []struct {
+		name string
+		exp  bool
+	}{
+		{"The.Movie.2019.2160p.UHD.BluRay.REMUX.HDR.HEVC.Atmos-GROUP", true},
+		{"The.Movie.1999.1080p.BluRay.x264-GROUP", false},
+		{"Some.Show.S02E03.1080p.WEB.H264-GROUP", true},
+		{"Some.Show.S02E04.1080p.WEB.H264-GROUP", false},
+		{"Other.Show.S01E01.2160p.WEB.H265-GROUP", true},
+	}
+	for i, test := range tests {
+		if _, ok := l.Owned(Torrent{Name: test.name}); ok != test.exp {
+			t.Errorf("test %d expected %t, got: %t", i, test.exp, ok)
+		}
+	}
+}
+
+func TestProfileDecide(t *testing.T) {
+	p := Profile{
+		Qualities: []ProfileQuality{
+			{Resolution: Resolution2160p, Source: SourceBluRay, Remux: true},
+			{Resolution: Resolution1080p, Source: SourceBluRay},
+			{Resolution: Resolution1080p, MaxSize: 10 << 30},
+		},
+		Upgrades: true,
+		Cutoff:   1,
+	}
+	web := Torrent{ID: 1, Name: "The.Movie.2019.1080p.WEB-DL.DDP5.1.H.264-GROUP", Size: 5 << 30}
+	bluray := Torrent{ID: 2, Name: "The.Movie.2019.1080p.BluRay.x264-GROUP", Size: 12 << 30}
+	remux := Torrent{ID: 3, Name: "The.Movie.2019.2160p.UHD.BluRay.REMUX.HDR.HEVC.Atmos-GROUP", Size: 60 << 30}
+	proper := Torrent{ID: 4, Name: "The.Movie.2019.PROPER.1080p.BluRay.x264-GROUP", Size: 12 << 30}
+	tests := []struct {
+		existing, candidate Torrent
+		exp                 Decision
+	}{
+		{Torrent{}, web, DecisionGrab},
+		{Torrent{}, Torrent{Name: "The.Movie.2019.720p.HDTV.x264-GROUP"}, DecisionSkip},
+		{Torrent{}, Torrent{Name: web.Name, Size: 20 << 30}, DecisionSkip},
+		{web, bluray, DecisionUpgrade},
+		{bluray, web, DecisionSkip},
+		{bluray, remux, DecisionSkip},
+		{bluray, proper, DecisionUpgrade},
+	}
+	for i, test := range tests {
+		if d := p.Decide(test.existing, test.candidate); d != test.exp {
+			t.Errorf("test %d expected %s, got: %s", i, test.exp, d)
+		}
+	}
+}
+
+func TestGroupFilter(t *testing.T) {
+	f := GroupFilter{
+		Allow: []string{"framestor", "*hd?"},
+		Deny:  []string{"evo", "yts*", "badhdx"},
+	}
+	tests := []struct {
+		name string
+		exp  bool
+	}{
+		{"The.Movie.2019.2160p.UHD.BluRay.REMUX.HDR.HEVC.Atmos-FraMeSToR", true},
+		{"Fight.Club.1999.1080p.BluRay.REMUX.AVC.DTS-HD.MA5.1-HDH", true},
+		{"The.Movie.2019.1080p.BluRay.x264-BADHDX", false},
+		{"The.Movie.2019.1080p.WEBRip.x264-YTS.MX", false},
+		{"The.Movie.2019.1080p.WEBRip.x264-EVO", false},
+		{"The.Movie.2019.1080p.WEBRip.x264-OTHER", false},
+		{"The.Movie.2019.1080p.WEBRip.x264", false},
+	}
+	for i, test := range tests {
+		if ok := f.Match(Torrent{Name: test.name}); ok != test.exp {
+			t.Errorf("test %d expected %t, got: %t", i, test.exp, ok)
+		}
+	}
+	if !(GroupFilter{Deny: []string{"evo"}}).Match(Torrent{Name: "The.Movie.2019.1080p.WEBRip.x264"}) {
+		t.Errorf("expected torrent without group to be allowed")
+	}
+}
+
+func TestRules(t *testing.T) {
+	_, err := LoadRules(strings.NewReader(`[{"name":"bad","match":"(unclosed"}]`))
+	var rerr *RuleError
+	if !errors.As(err, &rerr) || rerr.Name != "bad" || rerr.Field != "match" {
+		t.Fatalf("expected rule error, got: %v", err)
+	}
+	rules, err := LoadRules(strings.NewReader(`[
+		{"name":"movie","match":"(?i)^the\\.movie\\.2019\\.2160p","exclude":"(?i)\\bcam\\b","groups":{"deny":["evo"]}},
+		{"name":"show","match":"^Some\\.Show\\.S\\d+E\\d+","minSeeders":5}
+	]`))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	names := []string{
+		"The.Movie.2019.2160p.UHD.BluRay.REMUX.HDR.HEVC.Atmos-GROUP",
+		"The.Movie.2019.2160p.CAM.x264-GROUP",
+		"The.Movie.2019.2160p.WEB.H265-EVO",
+		"Some.Show.S01E02.1080p.WEB.H264-GROUP",
+	}
+	res := rules.Test(names)
+	if len(res) != 2 || len(res[0].Matches) != 1 || res[0].Matches[0] != names[0] || len(res[1].Matches) != 1 {
+		t.Errorf("unexpected test results: %+v", res)
+	}
+	if _, ok := rules.Match(Torrent{Name: names[3], Seeders: 1}); ok {
+		t.Errorf("expected no match for torrent with too few seeders")
+	}
+	if rule, ok := rules.Match(Torrent{Name: names[3], Seeders: 10}); !ok || rule.Name != "show" {
+		t.Errorf("expected show rule match, got: %+v %t", rule, ok)
+	}
+}
+
+func TestParseBrowseHTML(t *testing.T) {
+	const page = `<html><body><table id="torrenttable">
+<tr><th>Name</th><th>Size</th></tr>
+<tr data-tid="1319660">
+<td><a href="/torrents/browse/index/categories/13">Bluray</a></td>
+<td><a href="/torrent/1319660">Fight.Club.1999.1080p.BluRay.REMUX.AVC.DTS-HD.MA5.1-HDH</a>
+<span class="tag">REMUX</span><span class="freeleech"></span></td>
+<td><a href="/download/1319660/Fight.Club.1999.1080p.BluRay.REMUX.AVC.DTS-HD.MA5.1-HDH.torrent">DL</a></td>
+<td class="size">29.53 GB</td><td class="seeders">1,024</td><td class="leechers">3</td>
+<td class="added">2019-01-02 03:04:05</td>
+</tr>
+</table>
+<div class="pagination"><a class="active" href="/torrents/browse/index/page/1">1</a><a href="/torrents/browse/index/page/2">2</a></div>
+</body></html>`
+	torrents, more, err := ParseBrowseHTML(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(torrents) != 1 || !more {
+		t.Fatalf("expected 1 torrent and more pages, got: %d %t", len(torrents), more)
+	}
+	tr := torrents[0]
+	switch {
+	case tr.ID != 1319660,
+		tr.Name != "Fight.Club.1999.1080p.BluRay.REMUX.AVC.DTS-HD.MA5.1-HDH",
+		tr.Filename != "Fight.Club.1999.1080p.BluRay.REMUX.AVC.DTS-HD.MA5.1-HDH.torrent",
+		tr.CategoryID != CategoryMoviesBluRay,
+		tr.Size>>30 != 29,
+		tr.Seeders != 1024,
+		tr.Leechers != 3,
+		tr.AddedTimestamp.Year() != 2019,
+		!tr.HasTag(TagRemux) || !tr.HasTag(TagFreeleech):
+		t.Errorf("unexpected torrent: %+v", tr)
+	}
+	if torrents, _, _ := ParseBrowseHTML(strings.NewReader("<html><body>login</body></html>")); torrents != nil {
+		t.Errorf("expected nil torrents, got: %v", torrents)
+	}
+}
+
+func TestBBCode(t *testing.T) {
+	const desc = "[center][b]Fight Club[/b] (1999)[/center]\n" +
+		"[url=https://www.imdb.com/title/tt0137523/]IMDb[/url] [img]https://example.com/poster.jpg[/img]\n" +
+		"[quote]The first rule[/quote]\n" +
+		"[list][*]1080p[*][i]Remux[/i][/list]\n" +
+		"[code][b]raw[/b][/code][unknown]"
+	if s, exp := BBCodeText(desc), "Fight Club (1999)\n"+
+		"IMDb (https://www.imdb.com/title/tt0137523/) https://example.com/poster.jpg\n"+
+		"> The first rule\n"+
+		"- 1080p\n- Remux\n"+
+		"[b]raw[/b]\n[unknown]"; s != exp {
+		t.Errorf("expected text:\n%s\ngot:\n%s", exp, s)
+	}
+	if s, exp := BBCodeMarkdown(desc), "**Fight Club** (1999)\n"+
+		"[IMDb](https://www.imdb.com/title/tt0137523/) ![](https://example.com/poster.jpg)\n"+
+		"> The first rule\n"+
+		"- 1080p\n- _Remux_\n"+
+		"```\n[b]raw[/b]\n```\n\\[unknown\\]"; s != exp {
+		t.Errorf("expected markdown:\n%s\ngot:\n%s", exp, s)
+	}
+	if s, exp := BBCodeMarkdown(`<p><strong>Fight Club</strong><br>[i]1999[/i]</p><script>x</script>`), "**Fight Club**\n_1999_"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	d, err := ParseDetailsHTML(strings.NewReader(`<html><body><h1>Fight.Club.1999</h1>` +
+		`<div class="poster"><img src="https://example.com/poster.jpg"></div>` +
+		`<div id="description"><b>Fight Club</b> <img src="https://example.com/s1.png"></div></body></html>`))
+	switch {
+	case err != nil:
+		t.Fatalf("expected no error, got: %v", err)
+	case d.Name != "Fight.Club.1999", d.Poster != "https://example.com/poster.jpg",
+		len(d.Images) != 1, d.DescriptionText() != "Fight Club https://example.com/s1.png":
+		t.Errorf("unexpected details: %+v", d)
+	}
+}
+
+func TestArtworkCache(t *testing.T) {
+	n := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("png"))
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	c := &ArtworkCache{Client: cl, Dir: t.TempDir()}
+	for i := 0; i < 2; i++ {
+		paths, err := c.Fetch(context.Background(), 1, "/poster", srv.URL+"/shot.jpg")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if len(paths) != 2 || filepath.Ext(paths[0]) != ".png" || filepath.Ext(paths[1]) != ".jpg" {
+			t.Errorf("unexpected paths: %v", paths)
+		}
+	}
+	if n != 2 {
+		t.Errorf("expected 2 requests, got: %d", n)
+	}
+}
+
+func TestDecodeNFO(t *testing.T) {
+	tests := []struct {
+		buf       []byte
+		stripANSI bool
+		exp       string
+	}{
+		{[]byte("\xdb\xdb\xb2\xb1 GROUP \xb0\r\n"), false, "██▓▒ GROUP ░\n"},
+		{[]byte("caf\xe9 cr\xe8me"), false, "café crème"},
+		{[]byte("already ✓ utf-8"), false, "already ✓ utf-8"},
+		{[]byte("\x1b[1;31mred\x1b[0m text\x1aSAUCE00"), true, "red text"},
+	}
+	for i, test := range tests {
+		if s := DecodeNFO(test.buf, test.stripANSI); s != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name, exp string
+	}{
+		{"Movie/2019: Cut?*.torrent", "Movie_2019_ Cut__.torrent"},
+		{"bad\x00\x1fname. . ", "badname"},
+		{"CON.torrent", "_CON.torrent"},
+		{"lpt1", "_lpt1"},
+		{"console.torrent", "console.torrent"},
+		{"", "_"},
+		{strings.Repeat("é", 200) + ".torrent", strings.Repeat("é", 116) + ".torrent"},
+	}
+	for i, test := range tests {
+		if s := SanitizeFilename(test.name); s != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+	}
+}
+
+func TestTorrentAvailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "HEAD" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.URL.Path != "/download/1/a" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-bittorrent")
+		w.Header().Set("Content-Disposition", `attachment; filename="Movie.2019.torrent"`)
+		w.Header().Set("Content-Length", "1234")
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	ok, info, err := cl.TorrentAvailable(context.Background(), 1)
+	if err != nil || !ok || info.Filename != "Movie.2019.torrent" || info.Size != 1234 {
+		t.Errorf("expected available torrent, got: %t %+v %v", ok, info, err)
+	}
+	if ok, _, err := cl.TorrentAvailable(context.Background(), 2); err != nil || ok {
+		t.Errorf("expected unavailable torrent, got: %t %v", ok, err)
+	}
+}
+
+func TestPager(t *testing.T) {
+	pages := [][]int{{1, 2}, {3}, {4, 5}}
+	var fetched []int
+	p := NewPager(2, 0, func(_ context.Context, page int) ([]int, bool, error) {
+		fetched = append(fetched, page)
+		return pages[page-1], page < len(pages), nil
+	})
+	v, err := p.All(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(v) != 3 || v[0] != 3 || v[2] != 5 || len(fetched) != 2 || p.Page() != 3 {
+		t.Errorf("unexpected results: %v %v %d", v, fetched, p.Page())
+	}
+}
+
+func TestSearchPages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var page int
+		if _, err := fmt.Sscanf(r.URL.Path[strings.LastIndex(r.URL.Path, "/page/"):], "/page/%d", &page); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"numFound":5,"page":%d,"perPage":2,"torrentList":[`, page)
+		for i := (page-1)*2 + 1; i <= page*2 && i <= 5; i++ {
+			if i != (page-1)*2+1 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"fid":"%d","name":"torrent %d","addedTimestamp":"2020-01-%02d 00:00:00"}`, i, i, 10-i)
+		}
+		fmt.Fprint(w, `]}`)
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	torrents, err := Search().WithNextDelay(0).All(context.Background(), cl)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(torrents) != 5 || torrents[4].ID != 5 {
+		t.Errorf("expected 5 torrents, got: %+v", torrents)
+	}
+	since := time.Date(2020, 1, 7, 0, 0, 0, 0, time.UTC)
+	if torrents, err = Search().WithNextDelay(0).Since(since).All(context.Background(), cl); err != nil || len(torrents) != 2 {
+		t.Errorf("expected 2 torrents since %v, got: %+v %v", since, torrents, err)
+	}
+}
+
+func TestPagerTimeout(t *testing.T) {
+	p := NewPager(1, 0, func(ctx context.Context, page int) ([]int, bool, error) {
+		<-ctx.Done()
+		return nil, false, ctx.Err()
+	})
+	p.Timeout = 10 * time.Millisecond
+	if p.Next(context.Background()) {
+		t.Fatalf("expected no results")
+	}
+	if err := p.Err(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got: %v", err)
+	}
+}
+
+func TestPagerRetries(t *testing.T) {
+	n := 0
+	p := NewPager(1, 0, func(ctx context.Context, page int) ([]int, bool, error) {
+		if n++; n < 3 {
+			return nil, false, &StatusError{StatusCode: http.StatusBadGateway}
+		}
+		return []int{1}, false, nil
+	})
+	p.Retries, p.Backoff = 2, time.Millisecond
+	if v, err := p.All(context.Background()); err != nil || len(v) != 1 {
+		t.Errorf("expected 1 result, got: %v %v", v, err)
+	}
+	if IsTransient(&StatusError{StatusCode: http.StatusForbidden}) {
+		t.Errorf("expected 403 to not be transient")
+	}
+}
+
+func TestPagerPartial(t *testing.T) {
+	p := NewPager(1, 0, func(ctx context.Context, page int) ([]int, bool, error) {
+		if page == 3 {
+			return nil, false, errors.New("page 3 failed")
+		}
+		return []int{page}, true, nil
+	})
+	if v, err := p.All(context.Background()); err == nil || len(v) != 2 {
+		t.Errorf("expected 2 partial results and an error, got: %v %v", v, err)
+	}
+}
+
+func TestSkipInvalid(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"numFound":3,"perPage":3,"torrentList":[{"fid":"1"},{"fid":"2","addedTimestamp":"bad"},{"fid":"3"}]}`)
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	if _, err := Search().Do(context.Background(), cl); err == nil {
+		t.Fatalf("expected error")
+	}
+	WithSkipInvalid(true)(cl)
+	res, err := Search().Do(context.Background(), cl)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(res.TorrentList) != 2 || len(res.Skipped) != 1 || res.Skipped[0].Index != 1 || res.NumFound != 3 {
+		t.Errorf("unexpected response: %+v", res)
+	}
+}
+
+func TestSearchValidate(t *testing.T) {
+	tests := []struct {
+		req *SearchRequest
+		ok  bool
+	}{
+		{Search().WithAdded(Added2Weeks).WithOrderBy(OrderByRating).WithOrder(OrderAsc), true},
+		{Search().WithAdded(Added24Hours), true},
+		{Search().WithAdded("36h"), false},
+		{Search().WithAdded("yesterday"), false},
+		{Search().WithOrderBy("popularity"), false},
+		{Search().WithOrder("up"), false},
+	}
+	for i, test := range tests {
+		if err := test.req.Validate(); (err == nil) != test.ok {
+			t.Errorf("test %d expected ok %t, got: %v", i, test.ok, err)
+		}
+	}
+}
+
+func TestFacetsWOC(t *testing.T) {
+	var res SearchResponse
+	if err := json.Unmarshal([]byte(`{"facetswoc":{
+		"categoryID":{"items":{"13":5,"47":2}},
+		"size":{"items":{"[0 TO 786432000]":3}},
+		"seeders":{"items":{"[0 TO 50]":{"label":"0-50","count":4}}},
+		"tags":{"items":{"REMUX":7}},
+		"genres":{"items":{"Drama":1}}
+	}}`), &res); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	woc := res.Facetswoc
+	if v := woc.CategoryID.Sorted(); len(v) != 2 || v[0].ID != 13 {
+		t.Errorf("unexpected categories: %+v", v)
+	}
+	if v := woc.Size.Ranges(); len(v) != 1 || v[0].Count != 3 || !v[0].Parsed {
+		t.Errorf("unexpected size ranges: %+v", v)
+	}
+	if v := woc.Seeders.Ranges(); len(v) != 1 || v[0].Label != "0-50" {
+		t.Errorf("unexpected seeders ranges: %+v", v)
+	}
+	if woc.Tags.Items[TagRemux] != 7 || woc.Other["genres"].Items["Drama"] != 1 {
+		t.Errorf("unexpected tags: %+v %+v", woc.Tags, woc.Other)
+	}
+}
+
+func TestSyncer(t *testing.T) {
+	var list []string
+	add := func(id int, ts string) {
+		list = append([]string{fmt.Sprintf(`{"fid":"%d","addedTimestamp":"%s"}`, id, ts)}, list...)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"numFound":%d,"perPage":100,"torrentList":[%s]}`, len(list), strings.Join(list, ","))
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	s := &Syncer{Client: cl}
+	ids := func(torrents []Torrent, err error) []int {
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		var v []int
+		for _, t := range torrents {
+			v = append(v, t.ID)
+		}
+		return v
+	}
+	add(1, "2020-01-01 00:00:00")
+	add(2, "2020-01-02 00:00:00")
+	if v := ids(s.Sync(context.Background(), Search("x").WithNextDelay(0))); len(v) != 2 {
+		t.Errorf("expected 2 torrents, got: %v", v)
+	}
+	add(3, "2020-01-02 00:00:00")
+	add(4, "2020-01-03 00:00:00")
+	if v := ids(s.Sync(context.Background(), Search("x").WithNextDelay(0))); len(v) != 2 || v[0] != 4 || v[1] != 3 {
+		t.Errorf("expected torrents 4 and 3, got: %v", v)
+	}
+	if v := ids(s.Sync(context.Background(), Search("x").WithNextDelay(0))); len(v) != 0 {
+		t.Errorf("expected no torrents, got: %v", v)
+	}
+}
+
+func TestTorrents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/torrent/1" || r.URL.Path == "/torrent/2" || r.URL.Path == "/torrent/4":
+			fmt.Fprintf(w, `<html><body><h1>Torrent.%s</h1></body></html>`, r.URL.Path[len("/torrent/"):])
+		case strings.HasPrefix(r.URL.Path, "/torrents/browse/list/"):
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"numFound":2,"perPage":100,"torrentList":[{"fid":"1","name":"Torrent.1","seeders":5},{"fid":"2","name":"Torrent.2"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	m, err := cl.Torrents(context.Background(), 1, 2, 3)
+	var errs IDErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].ID != 3 || !errors.Is(errs[0], ErrNotFound) {
+		t.Errorf("expected not found error for torrent 3, got: %v", err)
+	}
+	if len(m) != 2 || m[1].Seeders != 5 {
+		t.Errorf("expected torrents 1 and 2, got: %+v", m)
+	}
+	if tr, err := cl.TorrentInfo(context.Background(), 2); err != nil || tr.Name != "Torrent.2" {
+		t.Errorf("expected torrent 2, got: %+v %v", tr, err)
+	}
+	if _, err := cl.TorrentInfo(context.Background(), 3); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found, got: %v", err)
+	}
+	if _, err := cl.TorrentInfo(context.Background(), 4); !errors.Is(err, ErrNotListed) || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not listed, got: %v", err)
+	}
+}
+
+func TestExists(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/torrent/1":
+			fmt.Fprint(w, `<html><body><h1>Torrent.1</h1></body></html>`)
+		case "/torrent/2":
+			fmt.Fprint(w, `<html><body><h1>Error</h1><div class="error">Torrent not found</div></body></html>`)
+		case "/torrent/3":
+			http.NotFound(w, r)
+		case "/torrent/5":
+			fmt.Fprint(w, `<html><body><h1>Torrent.5</h1><div class="alert nuked">Nuked: dupe</div></body></html>`)
+		case "/torrent/6":
+			fmt.Fprint(w, `<html><body><h1>Torrent.6</h1><div class="comment">Sample link not found</div></body></html>`)
+		case "/torrent/7":
+			fmt.Fprint(w, `<html><body><h1>Torrent.7</h1><div id="description">x</div><div class="comment">File does not exist</div></body></html>`)
+		case "/torrent/8":
+			fmt.Fprint(w, `<html><body><h1>Torrent not found</h1></body></html>`)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	for id, exp := range map[int]bool{1: true, 2: false, 3: false, 5: true, 6: true, 7: true, 8: false} {
+		if ok, err := cl.Exists(context.Background(), id); err != nil || ok != exp {
+			t.Errorf("torrent %d expected %t, got: %t %v", id, exp, ok, err)
+		}
+	}
+	if _, err := cl.Exists(context.Background(), 4); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestStats(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "12345")
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	res, err := cl.Get(context.Background(), "/ok")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	_, _ = io.ReadAll(res.Body)
+	res.Body.Close()
+	if _, err := cl.Get(context.Background(), "/missing"); err == nil {
+		t.Fatalf("expected error")
+	}
+	s := cl.Stats()
+	if s.Requests != 2 || s.Bytes < 5 || s.Errors[ErrorClassHTTP4xx] != 1 || s.AvgLatency() <= 0 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+}
+
+func TestFileLimiter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limiter")
+	a, b := NewFileLimiter(path, 2, 50*time.Millisecond), NewFileLimiter(path, 2, 50*time.Millisecond)
+	start := time.Now()
+	for _, l := range []Limiter{a, b, a} {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("expected wait of at least 40ms, got: %v", d)
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	var n int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"path":%q}`, r.URL.Path)
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	c, err := NewDiskCache(dir, time.Hour, 200)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	get := func(c *DiskCache, path string) string {
+		cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"), WithCache(c))
+		res, err := cl.Get(context.Background(), path)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		defer res.Body.Close()
+		buf, _ := io.ReadAll(res.Body)
+		return string(buf)
+	}
+	for i := 0; i < 2; i++ {
+		if s := get(c, "/a"); s != `{"path":"/a"}` {
+			t.Errorf("unexpected body: %q", s)
+		}
+	}
+	if n != 1 {
+		t.Errorf("expected 1 request, got: %d", n)
+	}
+	// reopened cache is warm
+	c, err = NewDiskCache(dir, time.Hour, 200)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if get(c, "/a"); n != 1 {
+		t.Errorf("expected 1 request, got: %d", n)
+	}
+	// /a is evicted
+	time.Sleep(10 * time.Millisecond)
+	get(c, "/b")
+	if _, _, ok := c.Get(srv.URL + "/a"); ok {
+		t.Errorf("expected /a to be evicted")
+	}
+	if _, _, ok := c.Get(srv.URL + "/b"); !ok {
+		t.Errorf("expected /b to be cached")
+	}
+	if err := c.Clear(); err != nil || c.Size() != 0 {
+		t.Errorf("expected empty cache, got: %d %v", c.Size(), err)
+	}
+	// write failures do not fail the request
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	var logged []string
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"), WithCache(c), WithLogf(func(s string, v ...interface{}) {
+		logged = append(logged, fmt.Sprintf(s, v...))
+	}))
+	res, err := cl.Get(context.Background(), "/c")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer res.Body.Close()
+	if buf, _ := io.ReadAll(res.Body); string(buf) != `{"path":"/c"}` {
+		t.Errorf("unexpected body: %q", buf)
+	}
+	if len(logged) != 1 || !strings.Contains(logged[0], "unable to store") {
+		t.Errorf("expected cache error logged, got: %q", logged)
+	}
+}
+
+func TestJSONLWriter(t *testing.T) {
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	w := NewJSONLWriter(bw)
+	if err := w.Write(Torrent{ID: 1, Name: "a & b"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s := buf.String(); s != `{"addedTimestamp":"0001-01-01T00:00:00Z","id":1,"name":"a & b"}`+"\n" {
+		t.Errorf("unexpected line: %q", s)
+	}
+	if err := w.WriteAll([]Torrent{{ID: 2}, {ID: 3}}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 3 {
+		t.Errorf("expected 3 lines, got: %d", n)
+	}
+}
+
+func TestUnknownFieldHandler(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"numFound":2,"newTop":1,"torrentList":[{"fid":"1","newField":"a"},{"fid":"2","newField":"b"}]}`)
+	}))
+	defer srv.Close()
+	var fields []string
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"), WithUnknownFieldHandler(func(endpoint, field string, value json.RawMessage) {
+		if !strings.HasPrefix(endpoint, "/torrents/browse/list") {
+			t.Errorf("unexpected endpoint: %s", endpoint)
+		}
+		fields = append(fields, field)
+	}))
+	res, err := Search("x").Do(context.Background(), cl)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(res.TorrentList) != 2 {
+		t.Errorf("expected 2 torrents, got: %d", len(res.TorrentList))
+	}
+	sort.Strings(fields)
+	if len(fields) != 2 || fields[0] != "newTop" || fields[1] != "torrentList.newField" {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+}
+
+func TestCallOptions(t *testing.T) {
+	var n, fail int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		if fail != 0 {
+			fail--
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"numFound":1,"torrentList":[{"fid":"1"}]}`)
+	}))
+	defer srv.Close()
+	c, err := NewDiskCache(t.TempDir(), time.Hour, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"), WithCache(c))
+	fail = 2
+	if _, err := Search("x").Do(context.Background(), cl); err == nil {
+		t.Fatalf("expected error")
+	}
+	res, err := Search("x").Do(context.Background(), cl, WithCallRetries(1), WithCallTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(res.TorrentList) != 1 || n != 3 {
+		t.Errorf("expected 1 torrent after 3 requests, got: %d %d", len(res.TorrentList), n)
+	}
+	if _, err := Search("x").Do(context.Background(), cl); err != nil || n != 3 {
+		t.Errorf("expected cached response, got: %d %v", n, err)
+	}
+	fail = 1
+	if _, err := Search("x").Do(context.Background(), cl, WithNoCache()); err == nil || n != 4 {
+		t.Errorf("expected uncached error, got: %d %v", n, err)
+	}
+}
+
+func TestPromo(t *testing.T) {
+	tests := []struct {
+		t   Torrent
+		exp PromoType
+	}{
+		{Torrent{}, PromoNone},
+		{Torrent{DownloadMultiplier: 1}, PromoNone},
+		{Torrent{Tags: []string{"x265", TagFreeleech}}, PromoFreeleech},
+		{Torrent{DownloadMultiplier: 50}, PromoNone},
+		{Torrent{Tags: []string{"Neutral"}}, PromoNeutralLeech},
+	}
+	for i, test := range tests {
+		if p := test.t.Promo(); p.Type != test.exp {
+			t.Errorf("test %d expected %v, got: %v", i, test.exp, p.Type)
+		}
+	}
+	if !(Promo{Type: PromoNeutralLeech}).Free() || (Promo{Type: PromoHalfLeech}).Free() {
+		t.Errorf("unexpected free values")
+	}
+	d, err := ParseDetailsHTML(strings.NewReader(`<h1>name</h1><div class="banner sitewide">Sitewide freeleech until <time datetime="2030-01-02T00:00:00Z">Jan 2</time></div>`))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if d.Promo == nil || d.Promo.Type != PromoSitewideFreeleech || d.Promo.Expires.Year() != 2030 {
+		t.Fatalf("expected sitewide freeleech promo, got: %+v", d.Promo)
+	}
+	if !d.Promo.Active(time.Now()) || d.Promo.Active(d.Promo.Expires) {
+		t.Errorf("unexpected active values")
+	}
+}
+
+func TestPollNew(t *testing.T) {
+	defer func(d time.Duration) { minPollInterval = d }(minPollInterval)
+	minPollInterval = 0
+	var mu sync.Mutex
+	list := []string{`{"fid":"1","addedTimestamp":"2020-01-01 00:00:00"}`}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"numFound":%d,"perPage":100,"torrentList":[%s]}`, len(list), strings.Join(list, ","))
+		// add 2 torrents after the first poll
+		if len(list) == 1 {
+			list = append([]string{
+				`{"fid":"3","addedTimestamp":"2020-01-03 00:00:00"}`,
+				`{"fid":"2","addedTimestamp":"2020-01-02 00:00:00"}`,
+			}, list...)
+		}
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var ids []int
+	err := cl.PollNew(ctx, 10*time.Millisecond, func(t Torrent) {
+		ids = append(ids, t.ID)
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != 2 || ids[1] != 3 {
+		t.Errorf("expected torrents 2 and 3, got: %v", ids)
+	}
+}
+
+func TestPollNewEmpty(t *testing.T) {
+	defer func(d time.Duration) { minPollInterval = d }(minPollInterval)
+	minPollInterval = 0
+	var mu sync.Mutex
+	var list []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"numFound":%d,"perPage":100,"torrentList":[%s]}`, len(list), strings.Join(list, ","))
+		// list a new and an old torrent after the first poll
+		if len(list) == 0 {
+			list = []string{
+				`{"fid":"2","addedTimestamp":"2099-01-01 00:00:00"}`,
+				`{"fid":"1","addedTimestamp":"2020-01-01 00:00:00"}`,
+			}
+		}
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var ids []int
+	err := cl.PollNew(ctx, 10*time.Millisecond, func(t Torrent) {
+		ids = append(ids, t.ID)
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != 2 {
+		t.Errorf("expected torrent 2, got: %v", ids)
+	}
+}
+
+func TestConservativeDefaults(t *testing.T) {
+	cl := New(WithConservativeDefaults(), WithCreds("a", "b", "c"))
+	if b, ok := cl.limiter.(*Budget); !ok || b.Remaining() != 30 {
+		t.Errorf("expected budget of 30, got: %v", cl.limiter)
+	}
+	if d := cl.pageDelay(5 * time.Second); d != 10*time.Second {
+		t.Errorf("expected 10s page delay, got: %v", d)
+	}
+	if o := cl.callOptions(context.Background()); o.retries != 3 || o.backoff != 5*time.Second {
+		t.Errorf("unexpected call options: %+v", o)
+	}
+	ctx := WithCallOptions(context.Background(), WithCallRetries(0))
+	if o := cl.callOptions(ctx); o.retries != 0 || o.backoff != 5*time.Second {
+		t.Errorf("unexpected call options: %+v", o)
+	}
+}
+
+func TestSearchRequestJSON(t *testing.T) {
+	req := Search("foo").WithCategories(CategoryTVEpisodes).WithOrderBy(OrderBySeeders).WithNextDelay(0)
+	buf, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s := string(buf); s != `{"categories":[26],"query":["foo"],"orderBy":"seeders","page":1}` {
+		t.Errorf("unexpected json: %s", s)
+	}
+	var v struct {
+		Request *SearchRequest `json:"request"`
+	}
+	if err := json.Unmarshal([]byte(`{"request":`+string(buf)+`}`), &v); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if v.Request.path() != req.path() || v.Request.d != 5*time.Second {
+		t.Errorf("expected %s, got: %s", req.path(), v.Request.path())
+	}
+	var r SearchRequest
+	if err := json.Unmarshal([]byte(`{"orderBy":"bogus"}`), &r); err == nil {
+		t.Errorf("expected error")
+	}
+	if err := r.UnmarshalYAML(func(v interface{}) error {
+		return json.Unmarshal(buf, v)
+	}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if p, _ := r.MarshalYAML(); p.(searchParams).OrderBy != OrderBySeeders {
+		t.Errorf("unexpected yaml params: %+v", p)
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/chunked" {
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, `{"numFound":1,"torrentList":[{"fid":"1","name":"`+strings.Repeat("x", 100)+`"}]}`)
+	}))
+	defer srv.Close()
+	for _, path := range []string{"/sized", "/chunked"} {
+		cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"), WithMaxBodySize(64))
+		var res SearchResponse
+		err := cl.GetJSON(context.Background(), path, &res)
+		var serr *BodySizeError
+		if !errors.As(err, &serr) || serr.Limit != 64 {
+			t.Errorf("%s expected body size error, got: %v", path, err)
+		}
+		cl = New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"), WithMaxBodySize(1024))
+		if err := cl.GetJSON(context.Background(), path, &res); err != nil {
+			t.Errorf("%s expected no error, got: %v", path, err)
+		}
+	}
+}
+
+func TestHasFacets(t *testing.T) {
+	tests := []struct {
+		s   string
+		exp bool
+	}{
+		{`{"numFound":0}`, false},
+		{`{"facets":null}`, false},
+		{`{"facets":[]}`, false},
+		{`{"facets":{"tags":null,"size":[],"categoryID":{"items":[]}}}`, false},
+		{`{"facets":{"size":"bogus","tags":{"items":{"REMUX":2}}}}`, true},
+		{`{"facets":{"categoryID":{"items":{"26":3}}},"facetswoc":[]}`, true},
+	}
+	for i, test := range tests {
+		var res SearchResponse
+		if err := json.Unmarshal([]byte(test.s), &res); err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if b := res.HasFacets(); b != test.exp {
+			t.Errorf("test %d expected %t, got: %t", i, test.exp, b)
+		}
+	}
+}
+
+func TestNuked(t *testing.T) {
+	var tr Torrent
+	if err := json.Unmarshal([]byte(`{"fid":"1","tags":["1080p","NUKED: bad.aspect.ratio"]}`), &tr); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !tr.Nuked || tr.NukeReason != "bad.aspect.ratio" {
+		t.Errorf("expected nuked torrent, got: %t %q", tr.Nuked, tr.NukeReason)
+	}
+	if err := json.Unmarshal([]byte(`{"fid":"2","tags":["1080p"]}`), &tr); err != nil || tr.Nuked {
+		t.Errorf("expected torrent not nuked, got: %t %v", tr.Nuked, err)
+	}
+	d, err := ParseDetailsHTML(strings.NewReader(`<h1>name</h1><div class="alert nuked">Nuked: dupe</div>`))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !d.Nuked || d.NukeReason != "dupe" {
+		t.Errorf("expected nuked details, got: %t %q", d.Nuked, d.NukeReason)
+	}
+}
+
+func TestInternalOnly(t *testing.T) {
+	req := Search("x").WithFacet(FacetTags, Tag1080p).WithInternalOnly("TLGRP")
+	if s := req.Facets[FacetTags]; s != Tag1080p+","+TagInternal {
+		t.Errorf("unexpected tags facet: %q", s)
+	}
+	// filters set later do not replace the internal check
+	filtered := req.WithFilter(func(t Torrent) bool {
+		return !strings.Contains(t.Name, "2021")
+	})
+	tests := []struct {
+		t   Torrent
+		exp bool
+	}{
+		{Torrent{Name: "Movie.2020.1080p.BluRay.x264-OTHER"}, false},
+		{Torrent{Name: "Movie.2020.1080p.BluRay.x264-OTHER", Tags: []string{"INTERNAL"}}, true},
+		{Torrent{Name: "Movie.2020.1080p.BluRay.x264-TLGrp"}, true},
+	}
+	for i, test := range tests {
+		if b := req.match(test.t); b != test.exp {
+			t.Errorf("test %d expected %t, got: %t", i, test.exp, b)
+		}
+		if b := filtered.match(test.t); b != test.exp {
+			t.Errorf("test %d expected filtered %t, got: %t", i, test.exp, b)
+		}
+	}
+	if filtered.match(Torrent{Name: "Movie.2021.1080p.BluRay.x264-TLGRP"}) {
+		t.Errorf("expected filter to apply")
+	}
+	if Search("x").WithInternalOnly().match(Torrent{Name: "Movie.2020.1080p.BluRay.x264-TLGRP"}) {
+		t.Errorf("expected groups to be per request")
+	}
+}
+
+func TestSearchMany(t *testing.T) {
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		counts[r.URL.Path]++
+		mu.Unlock()
+		if strings.Contains(r.URL.Path, "/query/bad/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"numFound":3,"perPage":100,"torrentList":[{"fid":"1"},{"fid":"2"},{"fid":"1"}]}`)
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	res, err := SearchMany(context.Background(), cl, []*SearchRequest{
+		Search("a").WithNextDelay(0),
+		Search("bad").WithNextDelay(0),
+		Search("a").WithNextDelay(0),
+		Search("b").WithNextDelay(0),
+	}, 2)
+	var errs QueryErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Index != 1 {
+		t.Fatalf("expected query 1 error, got: %v", err)
+	}
+	if len(res) != 4 || len(res[0]) != 2 || len(res[1]) != 0 || len(res[2]) != 2 || len(res[3]) != 2 {
+		t.Errorf("unexpected results: %v", res)
+	}
+	if n := counts["/torrents/browse/list/query/a/page/1"]; n != 1 {
+		t.Errorf("expected query a to be executed once, got: %d", n)
+	}
+}
+
+func TestWishlist(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(r.URL.Path, "/query/tt0133093/"):
+			fmt.Fprint(w, `{"numFound":2,"perPage":100,"torrentList":[`+
+				`{"fid":"1","name":"The.Matrix.1999.720p.BluRay.x264-A","imdbID":"tt0133093","seeders":5},`+
+				`{"fid":"2","name":"The.Matrix.1999.2160p.UHD.BluRay.REMUX-B","imdbID":"tt0133093","seeders":1}]}`)
+		default:
+			fmt.Fprint(w, `{"numFound":2,"perPage":100,"torrentList":[`+
+				`{"fid":"3","name":"Other.Movie.2020.1080p.WEB-DL-C"},`+
+				`{"fid":"4","name":"Some.Movie.2020.1080p.WEB-DL-C","seeders":3}]}`)
+		}
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	w := NewWishlist(
+		WishlistItem{Title: "The Matrix", ImdbID: "tt0133093", Profile: Profile{Qualities: []ProfileQuality{{Resolution: Resolution2160p}}}},
+		WishlistItem{Title: "Some Movie", Year: 2020},
+	)
+	ids := func(matches []WishlistMatch, err error) []int {
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		var v []int
+		for _, m := range matches {
+			v = append(v, m.Torrent.ID)
+		}
+		return v
+	}
+	if v := ids(w.Check(context.Background(), cl)); len(v) != 2 || v[0] != 2 || v[1] != 4 {
+		t.Errorf("expected torrents 2 and 4, got: %v", v)
+	}
+	var buf bytes.Buffer
+	if err := w.Save(&buf); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	w, err := LoadWishlist(&buf)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if v := ids(w.Check(context.Background(), cl)); len(v) != 0 {
+		t.Errorf("expected no torrents, got: %v", v)
+	}
+}
+
+func TestSanitizer(t *testing.T) {
+	s := NewSanitizer().AddSecret("myuser")
+	s.Add(regexp.MustCompile(`secret-\d+`), "[secret]")
+	tests := []struct {
+		s, exp string
+	}{
+		{"Cookie: PHPSESSID=abc; tluid=123", "Cookie: REDACTED"},
+		{"GET https://www.torrentleech.org/rss/download/1/0123456789abcdef0123456789abcdef/x.torrent", "GET https://www.torrentleech.org/rss/download/1/REDACTED/x.torrent"},
+		{"url ?passkey=xyz&id=1 tlpass=p1", "url ?passkey=REDACTED&id=1 tlpass=REDACTED"},
+		{"user myuser has secret-42", "user REDACTED has [secret]"},
+		{"hash 0123456789abcdef0123456789abcdef01234567", "hash 0123456789abcdef0123456789abcdef01234567"},
+	}
+	for i, test := range tests {
+		if s := s.Sanitize(test.s); s != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+	}
+	var logs []string
+	cl := New(WithCreds("a", "b", "c"), WithLogf(func(s string, v ...interface{}) {
+		logs = append(logs, fmt.Sprintf(s, v...))
+	}))
+	cl.logf("retrying %s", "https://host/?torrent_pass=abc")
+	if len(logs) != 1 || logs[0] != "retrying https://host/?torrent_pass=REDACTED" {
+		t.Errorf("unexpected logs: %q", logs)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/":
+			fmt.Fprint(w, `<html>home</html>`)
+		case r.URL.Path == "/user/account/login" && r.Method == "GET":
+			fmt.Fprint(w, `<form method="post" action="/user/account/login"><input type="hidden" name="csrf" value="tok"><input type="text" name="username"><input type="password" name="password"></form>`)
+		case r.URL.Path == "/user/account/login":
+			if r.PostFormValue("csrf") != "tok" || r.PostFormValue("username") != "user" || r.PostFormValue("password") != "pass" {
+				fmt.Fprint(w, `<div class="alert alert-danger">Invalid username or password</div><form method="post"><input type="password" name="password"></form>`)
+				return
+			}
+			fmt.Fprint(w, `<form method="post" action="/user/account/2fa"><input type="text" name="otpkey"></form>`)
+		case r.URL.Path == "/user/account/2fa":
+			if r.PostFormValue("otpkey") != "123456" {
+				http.Error(w, "bad code", http.StatusForbidden)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "tluid", Value: "1", Path: "/"})
+			http.SetCookie(w, &http.Cookie{Name: "tlpass", Value: "p", Path: "/"})
+			http.Redirect(w, r, "/", http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithOTPPrompt(func(context.Context) (string, error) {
+		return "123456", nil
+	}))
+	err := cl.Login(context.Background(), "user", "bad")
+	var lerr *LoginError
+	if !errors.As(err, &lerr) || !errors.Is(err, ErrLoginFailed) || lerr.Message != "Invalid username or password" {
+		t.Fatalf("expected login error, got: %v", err)
+	}
+	if err := cl.Login(context.Background(), "user", "pass"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	u, _ := url.Parse(srv.URL)
+	if n := len(cl.Jar.Cookies(u)); n != 2 {
+		t.Errorf("expected 2 cookies, got: %d", n)
+	}
+}
+
+func TestLoginExistingCookies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/user/account/login" && r.Method == "GET":
+			fmt.Fprint(w, `<form method="post"><input type="text" name="username"><input type="password" name="password"></form>`)
+		case r.URL.Path == "/user/account/login":
+			if r.PostFormValue("password") != "pass" {
+				fmt.Fprint(w, `<div class="alert">Invalid username or password</div><form method="post"><input type="password" name="password"></form>`)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "tluid", Value: "2", Path: "/"})
+			http.SetCookie(w, &http.Cookie{Name: "tlpass", Value: "new", Path: "/"})
+			http.Redirect(w, r, "/", http.StatusFound)
+		default:
+			fmt.Fprint(w, `<html>home</html>`)
+		}
+	}))
+	defer srv.Close()
+	jar, err := NewJar(srv.URL, &http.Cookie{Name: "tluid", Value: "1"}, &http.Cookie{Name: "tlpass", Value: "old"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	cl := New(WithBaseURL(srv.URL), WithJar(jar))
+	u, _ := url.Parse(srv.URL)
+	cookies := func() string {
+		var v []string
+		for _, c := range cl.Jar.Cookies(u) {
+			v = append(v, c.Name+"="+c.Value)
+		}
+		sort.Strings(v)
+		return strings.Join(v, ";")
+	}
+	if err := cl.Login(context.Background(), "user", "bad"); !errors.Is(err, ErrLoginFailed) {
+		t.Fatalf("expected login error, got: %v", err)
+	}
+	if s := cookies(); s != "tlpass=old;tluid=1" {
+		t.Errorf("expected previous cookies restored, got: %s", s)
+	}
+	if err := cl.Login(context.Background(), "user", "pass"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s := cookies(); s != "tlpass=new;tluid=2" {
+		t.Errorf("expected new cookies, got: %s", s)
+	}
+}
+
+func TestDownloadURL(t *testing.T) {
+	tr := Torrent{ID: 12, Name: "Some Movie 2020 1080p"}
+	cl := New(WithCreds("a", "b", "c"))
+	if s := cl.DownloadURL(tr); s != "https://www.torrentleech.org/download/12/Some%20Movie%202020%201080p.torrent" {
+		t.Errorf("unexpected download url: %s", s)
+	}
+	cl = New(WithCreds("a", "b", "c"), WithPasskey("0123456789abcdef0123456789abcdef"))
+	if s := cl.DownloadURL(tr); s != "https://www.torrentleech.org/rss/download/12/0123456789abcdef0123456789abcdef/Some%20Movie%202020%201080p.torrent" {
+		t.Errorf("unexpected rss download url: %s", s)
+	}
+}
+
+func TestPasskey(t *testing.T) {
+	const passkey = "0123456789abcdef0123456789abcdef"
+	var downloads int
+	started, release := make(chan bool, 1), make(chan bool)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/download/7/a" {
+			downloads++
+			started <- true
+			<-release
+			w.Header().Set("Content-Type", "application/x-bittorrent")
+			info := "d6:lengthi1024e4:name8:test.bin12:piece lengthi16384e6:pieces20:aaaaaaaaaaaaaaaaaaaae"
+			fmt.Fprint(w, "d8:announce71:https://tracker.example.org/a/"+passkey+"/announce4:info"+info+"e")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"numFound":1,"perPage":50,"torrentList":[{"fid":"7","name":"a"}]}`)
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	// download urls are not blocked by the passkey download
+	go func() {
+		<-started
+		done := make(chan string)
+		go func() {
+			done <- cl.DownloadURL(Torrent{ID: 7, Name: "a"})
+		}()
+		select {
+		case s := <-done:
+			if s != srv.URL+"/download/7/a.torrent" {
+				t.Errorf("unexpected download url: %s", s)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("expected download url while downloading")
+		}
+		close(release)
+	}()
+	for i := 0; i < 2; i++ {
+		s, err := cl.Passkey(context.Background())
+		if err != nil || s != passkey {
+			t.Fatalf("expected passkey %s, got: %q %v", passkey, s, err)
+		}
+	}
+	if s, err := cl.Announce(context.Background()); err != nil || s != "https://tracker.example.org/a/"+passkey+"/announce" {
+		t.Errorf("unexpected announce url: %q %v", s, err)
+	}
+	if downloads != 1 {
+		t.Errorf("expected 1 download, got: %d", downloads)
+	}
+	m := &Metainfo{Announce: "https://tracker.example.org/announce.php?passkey=abc"}
+	if s := m.Passkey(); s != "abc" {
+		t.Errorf("expected passkey abc, got: %q", s)
+	}
+}
+
+func TestScrape(t *testing.T) {
+	const passkey = "0123456789abcdef0123456789abcdef"
+	hash := strings.Repeat("ab", 20)
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/download/7/a":
+			w.Header().Set("Content-Type", "application/x-bittorrent")
+			announce := srv.URL + "/a/" + passkey + "/announce"
+			info := "d6:lengthi1024e4:name8:test.bin12:piece lengthi16384e6:pieces20:aaaaaaaaaaaaaaaaaaaae"
+			fmt.Fprintf(w, "d8:announce%d:%s4:info%se", len(announce), announce, info)
+		case "/a/" + passkey + "/scrape":
+			h, _ := hex.DecodeString(hash)
+			if r.URL.Query().Get("info_hash") != string(h) {
+				fmt.Fprint(w, "d14:failure reason12:invalid hashe")
+				return
+			}
+			fmt.Fprintf(w, "d5:filesd20:%sd8:completei5e10:downloadedi50e10:incompletei2eeee", h)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"numFound":1,"perPage":50,"torrentList":[{"fid":"7","name":"a"}]}`)
+		}
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	stats, err := cl.Scrape(context.Background(), strings.ToUpper(hash))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s := stats[hash]; len(stats) != 1 || s.Seeders != 5 || s.Leechers != 2 || s.Completed != 50 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if _, err := cl.Scrape(context.Background(), strings.Repeat("cd", 20)); err == nil || !strings.Contains(err.Error(), "invalid hash") {
+		t.Errorf("expected tracker failure, got: %v", err)
+	}
+	if _, err := cl.Scrape(context.Background(), "xyz"); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestFlareSolverr(t *testing.T) {
+	var solves int
+	solver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v struct {
+			Cmd string `json:"cmd"`
+			URL string `json:"url"`
+		}
+		if r.URL.Path != "/v1" || json.NewDecoder(r.Body).Decode(&v) != nil || v.Cmd != "request.get" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		solves++
+		fmt.Fprintf(w, `{"status":"ok","message":"","solution":{"url":%q,"status":200,"userAgent":"solver","cookies":[{"name":"cf_clearance","value":"ok","path":"/","expires":%d},{"name":"PHPSESSID","value":"anon","path":"/","expires":-1}]}}`, v.URL, time.Now().Add(time.Hour).Unix())
+	}))
+	defer solver.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := r.Cookie("cf_clearance")
+		if err != nil || c.Value != "ok" || r.UserAgent() != "solver" {
+			w.Header().Set("Cf-Mitigated", "challenge")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"numFound":0,"perPage":50,"torrentList":[]}`)
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"), WithFlareSolverr(solver.URL+"/"))
+	for i := 0; i < 2; i++ {
+		if _, err := Search().Do(context.Background(), cl); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	if solves != 1 {
+		t.Errorf("expected 1 solve, got: %d", solves)
+	}
+	// the solver's session cookie must not replace the client's
+	u, _ := url.Parse(strings.Replace(srv.URL, "http://", "https://", 1))
+	for _, c := range cl.Jar.Cookies(u) {
+		if c.Name == "PHPSESSID" && c.Value != "a" {
+			t.Errorf("expected session a, got: %s", c.Value)
+		}
+	}
+}
+
+func TestUploadDraftValidate(t *testing.T) {
+	info := "d6:lengthi1024e4:name8:test.bin12:piece lengthi16384e6:pieces20:aaaaaaaaaaaaaaaaaaaae"
+	buf := []byte("d8:announce42:https://tracker.example/a/passkey/announce4:info" + info + "e")
+	d := &UploadDraft{Category: 14, Torrent: buf, NFO: []byte("nfo")}
+	if err := d.Validate(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	bad := []byte(strings.Replace(string(buf), "lengthi16384e", "lengthi1000e", 1))
+	d = &UploadDraft{Name: "Bad: Name ", Torrent: bad, NFO: make([]byte, 200<<10)}
+	var errs DraftErrors
+	if err := d.Validate(); !errors.As(err, &errs) {
+		t.Fatalf("expected draft errors, got: %v", err)
+	}
+	var fields []string
+	for _, err := range errs {
+		fields = append(fields, err.Field)
+	}
+	if s := strings.Join(fields, ","); s != "category,torrent,nfo,name,name" {
+		t.Errorf("unexpected problems %s: %v", s, errs)
+	}
+	if err := (&UploadDraft{Category: 14, Torrent: []byte("x")}).Validate(); err == nil || !strings.Contains(err.Error(), "torrent: invalid metainfo") {
+		t.Errorf("expected invalid torrent, got: %v", err)
+	}
+}
+
+func TestCheckDuplicate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Path, "/query/") || !strings.Contains(r.URL.Path, "2019") {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"numFound":4,"perPage":50,"torrentList":[`+
+			`{"fid":"1","name":"The Movie 2019 1080p BluRay x264-GRP","size":1000000},`+
+			`{"fid":"2","name":"The.Movie.2019.1080p.WEB-DL.H264-OTHER","size":5000000},`+
+			`{"fid":"3","name":"The.Movie.2019.720p.WEB-DL.H264-OTHER","size":5000000},`+
+			`{"fid":"4","name":"The.Movie.2019.2160p.BluRay.x265-GRP","size":9000000}]}`)
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	dupes, err := cl.CheckDuplicate(context.Background(), "The.Movie.2019.1080p.BluRay.x264-GRP", 5000001)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(dupes) != 2 || dupes[0].Torrent.ID != 1 || !dupes[0].SameName || dupes[0].SameSize || dupes[1].Torrent.ID != 2 || !dupes[1].SameSize {
+		t.Errorf("unexpected duplicates: %+v", dupes)
+	}
+}
+
+func TestCloudflareChallenge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("q") {
+		case "header":
+			w.Header().Set("Cf-Mitigated", "challenge")
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "page":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<html><head><title>Just a moment...</title></head><body><script src="/cdn-cgi/challenge-platform/h/b/orchestrate/chl_page/v1"></script></body></html>`)
+		case "ok":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><head><title>Just a moment...</title></head></html>`)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	for _, q := range []string{"header", "page", "ok"} {
+		var v interface{}
+		err := cl.GetJSON(context.Background(), "/?q="+q, &v)
+		if !errors.Is(err, ErrCloudflareChallenge) {
+			t.Errorf("%s: expected challenge error, got: %v", q, err)
+		}
+	}
+	var v interface{}
+	err := cl.GetJSON(context.Background(), "/?q=forbidden", &v)
+	var statusErr *StatusError
+	if errors.Is(err, ErrCloudflareChallenge) || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+		t.Errorf("expected forbidden error, got: %v", err)
+	}
+}
+
+func TestWatchTorrents(t *testing.T) {
+	defer func(d time.Duration) { minPollInterval = d }(minPollInterval)
+	minPollInterval = 0
+	var mu sync.Mutex
+	polls := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/torrent/1" || r.URL.Path == "/torrent/2" || r.URL.Path == "/torrent/3":
+			// torrent 3 is never listed by the search, but exists
+			if polls[r.URL.Path]++; r.URL.Path == "/torrent/2" && polls[r.URL.Path] >= 3 {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprintf(w, `<html><body><h1>Torrent.%s</h1></body></html>`, r.URL.Path[len("/torrent/"):])
+		case strings.HasPrefix(r.URL.Path, "/torrents/browse/list/"):
+			seeders, comments := 5, 0
+			switch n := polls["/torrent/1"]; {
+			case n == 2:
+				comments = 1
+			case n >= 3:
+				seeders, comments = 7, 1
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"numFound":2,"perPage":100,"torrentList":[{"fid":"1","name":"Torrent.1","seeders":%d,"numComments":%d},{"fid":"2","name":"Torrent.2"}]}`, seeders, comments)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var events []string
+	err := cl.WatchTorrents(ctx, []int{1, 2, 3}, time.Millisecond, func(ev WatchEvent) {
+		events = append(events, fmt.Sprintf("%d:%s", ev.ID, ev.Type))
+		if len(events) == 3 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context canceled, got: %v", err)
+	}
+	if s := strings.Join(events, ","); s != "1:comments,1:seeders,2:deleted" {
+		t.Errorf("unexpected events: %s", s)
+	}
+}
+
+func TestBlockPauseRefresh(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("cf_clearance"); err != nil || c.Value != "fresh" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"numFound":0,"perPage":50,"torrentList":[]}`)
+	}))
+	defer srv.Close()
+	var refreshes, pauses int
+	var fresh bool
+	cl := New(
+		WithBaseURL(srv.URL),
+		WithCreds("a", "b", "c"),
+		WithBlockPause(2, func(error) { pauses++ }),
+		OnCookieExpired(func(context.Context) ([]*http.Cookie, error) {
+			refreshes++
+			if !fresh {
+				return nil, errors.New("still blocked")
+			}
+			return []*http.Cookie{{Name: "cf_clearance", Value: "fresh"}}, nil
+		}),
+	)
+	for i := 0; i < 2; i++ {
+		if _, err := Search().Do(context.Background(), cl); err == nil {
+			t.Fatalf("expected error")
+		}
+	}
+	if !cl.Paused() || pauses != 1 {
+		t.Fatalf("expected paused client, got: %t %d", cl.Paused(), pauses)
+	}
+	if _, err := Search().Do(context.Background(), cl); !errors.Is(err, ErrPaused) || refreshes != 3 {
+		t.Fatalf("expected paused error after refresh, got: %v %d", err, refreshes)
+	}
+	fresh = true
+	if _, err := Search().Do(context.Background(), cl); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cl.Paused() || refreshes != 4 {
+		t.Errorf("expected resumed client, got: %t %d", cl.Paused(), refreshes)
+	}
+}
+
+func TestTorrentIndexError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-bittorrent")
+		fmt.Fprint(w, "not bencoded")
+	}))
+	defer srv.Close()
+	var logged []string
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"), WithHashIndex(NewHashIndex()), WithLogf(func(s string, v ...interface{}) {
+		logged = append(logged, fmt.Sprintf(s, v...))
+	}))
+	buf, err := cl.Torrent(context.Background(), 1)
+	if err != nil || string(buf) != "not bencoded" {
+		t.Fatalf("expected download, got: %q %v", buf, err)
+	}
+	if len(logged) != 1 || !strings.Contains(logged[0], "unable to index") {
+		t.Errorf("expected index error logged, got: %q", logged)
+	}
+}
+
+func TestExport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var page int
+		if _, err := fmt.Sscanf(r.URL.Path[strings.LastIndex(r.URL.Path, "/page/"):], "/page/%d", &page); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if page == 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		// later pages respond first
+		time.Sleep(time.Duration(5-page) * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"numFound":7,"page":%d,"perPage":2,"torrentList":[`, page)
+		for i := (page-1)*2 + 1; i <= page*2 && i <= 7; i++ {
+			if i != (page-1)*2+1 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"fid":"%d","name":"torrent %d"}`, i, i)
+		}
+		fmt.Fprint(w, `]}`)
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"))
+	torrents, err := Search().WithNextDelay(0).Export(context.Background(), cl, 3)
+	var errs PageErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Page != 3 {
+		t.Errorf("expected page 3 error, got: %v", err)
+	}
+	var ids []string
+	for _, tr := range torrents {
+		ids = append(ids, strconv.Itoa(tr.ID))
+	}
+	if s := strings.Join(ids, ","); s != "1,2,3,4,7" {
+		t.Errorf("expected torrents in page order, got: %s", s)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var ids []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get("X-Request-ID"))
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"), WithRequestID(func() string { return "gen" }))
+	_, err := cl.Get(context.Background(), "/")
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) || reqErr.RequestID != "gen" || !strings.Contains(err.Error(), "(request gen)") {
+		t.Errorf("expected request error with id gen, got: %v", err)
+	}
+	if _, err := cl.Get(WithContextRequestID(context.Background(), "ctx"), "/"); !errors.As(err, &reqErr) || reqErr.RequestID != "ctx" {
+		t.Errorf("expected request error with id ctx, got: %v", err)
+	}
+	cl = New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"), WithRequestID(nil))
+	_, _ = cl.Get(context.Background(), "/")
+	if len(ids) != 3 || ids[0] != "gen" || ids[1] != "ctx" || !regexp.MustCompile(`^[0-9a-f]{16}$`).MatchString(ids[2]) {
+		t.Errorf("unexpected request ids: %q", ids)
+	}
+}
+
+func TestDoHResolver(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"numFound":1,"perPage":50,"torrentList":[{"fid":"1","name":%q}]}`, r.Host)
+	}))
+	defer srv.Close()
+	var queries int
+	doh := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, _ := io.ReadAll(r.Body)
+		var msg dnsmessage.Message
+		if r.Header.Get("Content-Type") != "application/dns-message" || msg.Unpack(buf) != nil || len(msg.Questions) != 1 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		queries++
+		q := msg.Questions[0]
+		msg.Header.Response = true
+		if q.Name.String() == "tl.example." && q.Type == dnsmessage.TypeA {
+			msg.Answers = []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: q.Class, TTL: 60},
+				Body:   &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}},
+			}}
+		} else {
+			msg.RCode = dnsmessage.RCodeNameError
+		}
+		buf, _ = msg.Pack()
+		w.Header().Set("Content-Type", "application/dns-message")
+		_, _ = w.Write(buf)
+	}))
+	defer doh.Close()
+	u, _ := url.Parse(srv.URL)
+	cl := New(WithDoHResolver(doh.URL), WithBaseURL("http://tl.example:"+u.Port()), WithCreds("a", "b", "c"))
+	for i := 0; i < 2; i++ {
+		res, err := Search().Do(context.Background(), cl)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if len(res.TorrentList) != 1 || res.TorrentList[0].Name != "tl.example:"+u.Port() {
+			t.Errorf("unexpected response: %+v", res.TorrentList)
+		}
+	}
+	if queries != 1 {
+		t.Errorf("expected 1 cached query, got: %d", queries)
+	}
+	cl = New(WithDoHResolver(doh.URL), WithBaseURL("http://missing.example:"+u.Port()), WithCreds("a", "b", "c"))
+	if _, err := Search().Do(context.Background(), cl); err == nil || !strings.Contains(err.Error(), "doh: missing.example") {
+		t.Errorf("expected doh error, got: %v", err)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	var logouts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/account/logout":
+			if c, err := r.Cookie("tlpass"); err != nil || c.Value != "p" {
+				t.Errorf("expected session cookies on logout")
+			}
+			logouts++
+			http.Redirect(w, r, "/user/account/login", http.StatusFound)
+		case "/user/account/login":
+			fmt.Fprint(w, `<form method="post"><input type="password" name="password"></form>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	jar, err := NewJar(srv.URL, &http.Cookie{Name: "tluid", Value: "1"}, &http.Cookie{Name: "tlpass", Value: "p"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	cl := New(WithBaseURL(srv.URL), WithJar(jar))
+	if err := cl.Logout(context.Background()); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	u, _ := url.Parse(srv.URL)
+	if cookies := cl.Jar.Cookies(u); logouts != 1 || len(cookies) != 0 {
+		t.Errorf("expected cleared cookies after logout, got: %d %v", logouts, cookies)
+	}
+}
+
+func TestOnCookieExpired(t *testing.T) {
+	var mu sync.Mutex
+	var forbidden int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("cf_clearance"); err != nil {
+			mu.Lock()
+			forbidden++
+			mu.Unlock()
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"numFound":0,"perPage":50,"torrentList":[]}`)
+	}))
+	defer srv.Close()
+	var refreshes int
+	cl := New(WithBaseURL(srv.URL), WithCreds("a", "b", "c"), OnCookieExpired(func(context.Context) ([]*http.Cookie, error) {
+		refreshes++
+		return []*http.Cookie{{Name: "cf_clearance", Value: strconv.Itoa(refreshes), Expires: time.Now().Add(200 * time.Millisecond)}}, nil
+	}))
+	// rejected request is refreshed and retried
+	for i := 0; i < 2; i++ {
+		if _, err := Search().Do(context.Background(), cl); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	if refreshes != 1 || forbidden != 1 {
+		t.Errorf("expected 1 refresh after 1 forbidden response, got: %d %d", refreshes, forbidden)
+	}
+	// expired clearance is refreshed once before sending
+	time.Sleep(250 * time.Millisecond)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := Search().Do(context.Background(), cl); err != nil {
+				t.Errorf("expected no error, got: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if refreshes != 2 || forbidden != 1 {
+		t.Errorf("expected 2 refreshes after 1 forbidden response, got: %d %d", refreshes, forbidden)
+	}
+}
+
+func TestDetectCaptcha(t *testing.T) {
+	u, _ := url.Parse("https://www.torrentleech.org/user/account/login")
+	tests := []struct {
+		s    string
+		kind string
+		key  string
+	}{
+		{`<div class="g-recaptcha" data-sitekey="k1"></div>`, CaptchaRecaptcha, "k1"},
+		{`<div class="form h-captcha" data-theme="dark" data-sitekey="k2"></div>`, CaptchaHcaptcha, "k2"},
+		{`<div data-sitekey='k3' class='cf-turnstile'></div>`, CaptchaTurnstile, "k3"},
+		{`<div class="login"><input name="username"></div>`, "", ""},
+	}
+	for i, test := range tests {
+		c := detectCaptcha(u, []byte(test.s))
+		switch {
+		case test.kind == "" && c != nil:
+			t.Errorf("test %d expected no challenge, got: %+v", i, c)
+		case test.kind == "":
+		case c == nil:
+			t.Errorf("test %d expected challenge, got: nil", i)
+		case c.Kind != test.kind, c.SiteKey != test.key, c.URL != u.String():
+			t.Errorf("test %d expected %s %s, got: %+v", i, test.kind, test.key, c)
+		}
+	}
+}
+
+func TestCaptcha(t *testing.T) {
+	var mu sync.Mutex
+	var solved bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == "POST":
+			solved = r.PostFormValue("h-captcha-response") == "token"
+			fmt.Fprint(w, "ok")
+		case solved:
+			fmt.Fprint(w, "nfo")
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><body><form><div class="h-captcha" data-sitekey="key"></div></form></body></html>`)
+		}
+	}))
+	defer srv.Close()
+	jar, err := NewJar(srv.URL)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	// no solver
+	_, err = New(WithBaseURL(srv.URL), WithJar(jar)).NFO(context.Background(), 1, false)
+	var captchaErr *CaptchaError
+	switch {
+	case !errors.Is(err, ErrCaptcha), !errors.As(err, &captchaErr):
+		t.Fatalf("expected captcha error, got: %v", err)
+	case captchaErr.Captcha.Kind != CaptchaHcaptcha, captchaErr.Captcha.SiteKey != "key":
+		t.Errorf("expected hcaptcha with key, got: %+v", captchaErr.Captcha)
+	}
+	// bad solution is not retried
+	var calls int
+	solver := func(token string) Option {
+		return WithCaptchaSolver(CaptchaSolverFunc(func(_ context.Context, c *Captcha) (string, error) {
+			calls++
+			if c.URL != srv.URL+"/torrents/torrent/nfo/1" {
+				t.Errorf("expected challenge url, got: %s", c.URL)
+			}
+			return token, nil
+		}))
+	}
+	if _, err := New(WithBaseURL(srv.URL), WithJar(jar), solver("bad")).NFO(context.Background(), 1, false); !errors.Is(err, ErrCaptcha) {
+		t.Errorf("expected captcha error, got: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 solver call, got: %d", calls)
+	}
+	// solved
+	calls = 0
+	s, err := New(WithBaseURL(srv.URL), WithJar(jar), solver("token")).NFO(context.Background(), 1, false)
+	switch {
+	case err != nil:
+		t.Fatalf("expected no error, got: %v", err)
+	case s != "nfo":
+		t.Errorf("expected nfo, got: %q", s)
+	case calls != 1:
+		t.Errorf("expected 1 solver call, got: %d", calls)
+	}
+}
+
+func TestWithExactPhrase(t *testing.T) {
+	tests := []struct {
+		phrase string
+		exp    string
+	}{
+		{"the office", `/query/dune%20%22the%20office%22/orderby/added/order/desc/page/1`},
+		{`  "the   office"  `, `/query/dune%20%22the%20office%22/orderby/added/order/desc/page/1`},
+		{` " `, `/query/dune/orderby/added/order/desc/page/1`},
+	}
+	for i, test := range tests {
+		req := Search("dune").WithOrderBy("added").WithOrder("desc").WithExactPhrase(test.phrase)
+		if s := req.path(); s != test.exp {
+			t.Errorf("test %d expected %s, got: %s", i, test.exp, s)
+		}
+	}
+	req := Search("dune")
+	req.WithExactPhrase("the office")
+	if len(req.Query) != 1 {
+		t.Errorf("expected original request to be unchanged, got: %q", req.Query)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/torrents/browse/autocomplete" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("query") {
+		case "the off":
+			fmt.Fprint(w, `["The Office","The Offer"]`)
+		case "dun":
+			fmt.Fprint(w, `{"suggestions":[{"value":"Dune"},{"title":"Dune Part Two"}]}`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+	defer srv.Close()
+	jar, err := NewJar(srv.URL)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	cl := New(WithBaseURL(srv.URL), WithJar(jar))
+	tests := []struct {
+		prefix string
+		exp    []string
+	}{
+		{" the off ", []string{"The Office", "The Offer"}},
+		{"dun", []string{"Dune", "Dune Part Two"}},
+		{"zzz", []string{}},
+		{"  ", nil},
+	}
+	for i, test := range tests {
+		v, err := cl.Suggest(context.Background(), test.prefix)
+		switch {
+		case err != nil:
+			t.Errorf("test %d expected no error, got: %v", i, err)
+		case len(v) != len(test.exp), strings.Join(v, "|") != strings.Join(test.exp, "|"):
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, v)
+		}
+	}
+}
+
+func TestIndexer(t *testing.T) {
+	var mu sync.Mutex
+	var downloads []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var id int
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/torrents/browse/list"):
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"numFound":4,"perPage":100,"torrentList":[{"fid":"1","name":"indexed"},{"fid":"2","name":"a"},{"fid":"3","name":"skip"},{"fid":"4","name":"b"}]}`)
+		case strings.HasPrefix(r.URL.Path, "/download/"):
+			fmt.Sscanf(r.URL.Path, "/download/%d/", &id)
+			mu.Lock()
+			downloads = append(downloads, id)
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/x-bittorrent")
+			fmt.Fprintf(w, "d4:infod6:lengthi%de4:name8:test.bin12:piece lengthi16384e6:pieces20:aaaaaaaaaaaaaaaaaaaaee", id*1024)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	store := memHashStore{1: {ID: 1, InfoHash: "x"}}
+	var indexed []HashEntry
+	ix := &Indexer{
+		Client: New(WithBaseURL(srv.URL), WithCreds("a", "b", "c")),
+		Store:  store,
+		Filter: func(t Torrent) bool {
+			return t.Name != "skip"
+		},
+		OnIndexed: func(e HashEntry) {
+			indexed = append(indexed, e)
+		},
+	}
+	n, err := ix.Index(context.Background(), Search().WithNextDelay(0))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if n != 2 || len(indexed) != 2 || len(downloads) != 2 || downloads[0] != 2 || downloads[1] != 4 {
+		t.Fatalf("expected torrents 2 and 4 indexed, got: %d %v %v", n, indexed, downloads)
+	}
+	for id, name := range map[int]string{2: "a", 4: "b"} {
+		e, ok := store.Hash(id)
+		switch {
+		case !ok:
+			t.Errorf("expected torrent %d in store", id)
+		case e.Name != name, len(e.InfoHash) != 40, e.Size != int64(id*1024):
+			t.Errorf("unexpected entry for torrent %d: %+v", id, e)
+		}
+	}
+	if e, _ := store.Hash(1); e.InfoHash != "x" {
+		t.Errorf("expected torrent 1 unchanged, got: %+v", e)
+	}
+}
+
+// memHashStore is an in-memory hash store.
+type memHashStore map[int]HashEntry
+
+func (s memHashStore) PutHash(e HashEntry) error {
+	s[e.ID] = e
+	return nil
+}
+
+func (s memHashStore) Hash(id int) (HashEntry, bool) {
+	e, ok := s[id]
+	return e, ok
+}
@@ -0,0 +1,121 @@
+package tlapi
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ScrapeStats are a torrent's live swarm stats, as reported by the tracker.
+type ScrapeStats struct {
+	Seeders   int `json:"seeders"`
+	Leechers  int `json:"leechers"`
+	Completed int `json:"completed"`
+}
+
+// Scrape scrapes the tracker for the live swarm stats of the info hashes
+// (hex encoded), using the scrape url derived from the account's announce
+// url (see Announce). Returns the stats keyed by lower case info hash.
+// Info hashes unknown to the tracker are not included.
+func (cl *Client) Scrape(ctx context.Context, infohashes ...string) (map[string]ScrapeStats, error) {
+	announce, err := cl.Announce(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("scrape: %w", err)
+	}
+	urlstr, err := scrapeURL(announce, infohashes)
+	if err != nil {
+		return nil, fmt.Errorf("scrape: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", urlstr, nil)
+	if err != nil {
+		return nil, err
+	}
+	if agent := cl.agent(); agent != "" {
+		req.Header.Set("User-Agent", agent)
+	}
+	res, err := cl.cl.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("scrape: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scrape: invalid http status %d", res.StatusCode)
+	}
+	buf, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("scrape: %w", err)
+	}
+	stats, err := parseScrape(buf)
+	if err != nil {
+		return nil, fmt.Errorf("scrape: %w", err)
+	}
+	return stats, nil
+}
+
+// scrapeURL returns the tracker's scrape url for the info hashes, derived
+// from the announce url by the http scrape convention (replacing the last
+// path segment's "announce" with "scrape").
+func scrapeURL(announce string, infohashes []string) (string, error) {
+	u, err := url.Parse(announce)
+	if err != nil {
+		return "", err
+	}
+	dir, file := path.Split(u.Path)
+	if !strings.HasPrefix(file, "announce") {
+		return "", fmt.Errorf("announce url %s does not support scrape", u.Redacted())
+	}
+	u.Path = dir + "scrape" + strings.TrimPrefix(file, "announce")
+	var params []string
+	if u.RawQuery != "" {
+		params = append(params, u.RawQuery)
+	}
+	for _, s := range infohashes {
+		hash, err := hex.DecodeString(s)
+		if err != nil || len(hash) != 20 {
+			return "", fmt.Errorf("invalid info hash %q", s)
+		}
+		params = append(params, "info_hash="+url.QueryEscape(string(hash)))
+	}
+	u.RawQuery = strings.Join(params, "&")
+	return u.String(), nil
+}
+
+// parseScrape parses a bencoded scrape response.
+func parseScrape(buf []byte) (map[string]ScrapeStats, error) {
+	d := &bdecoder{buf: buf}
+	v, err := d.decode()
+	if err != nil {
+		return nil, fmt.Errorf("invalid scrape response: %w", err)
+	}
+	root, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid scrape response: not a dictionary")
+	}
+	if reason := bstring(root["failure reason"]); reason != "" {
+		return nil, fmt.Errorf("tracker failure: %s", reason)
+	}
+	files, _ := root["files"].(map[string]interface{})
+	stats := make(map[string]ScrapeStats, len(files))
+	for hash, v := range files {
+		file, ok := v.(map[string]interface{})
+		if !ok || len(hash) != 20 {
+			return nil, errors.New("invalid scrape response: invalid file")
+		}
+		stats[hex.EncodeToString([]byte(hash))] = ScrapeStats{
+			Seeders:   int(bint(file["complete"])),
+			Leechers:  int(bint(file["incomplete"])),
+			Completed: int(bint(file["downloaded"])),
+		}
+	}
+	return stats, nil
+}
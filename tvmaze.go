@@ -0,0 +1,150 @@
+package tlapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EpisodeInfo is TVMaze episode information.
+type EpisodeInfo struct {
+	ShowID  string    `json:"showID"`
+	ID      int       `json:"id"`
+	Season  int       `json:"season"`
+	Episode int       `json:"episode"`
+	Title   string    `json:"title,omitempty"`
+	AirDate time.Time `json:"airDate,omitempty"`
+	Runtime int       `json:"runtime,omitempty"`
+	Summary string    `json:"summary,omitempty"`
+	URL     string    `json:"url,omitempty"`
+}
+
+// episodeRE matches season/episode numbers in release names.
+var episodeRE = regexp.MustCompile(`(?i)\bS(\d{1,3})[ ._-]?E(\d{1,4})\b`)
+
+// dailyRE matches air dates in daily release names.
+var dailyRE = regexp.MustCompile(`\b((?:19|20)\d\d)[ ._-](\d\d)[ ._-](\d\d)\b`)
+
+// ParseEpisode parses the season and episode numbers from a release name
+// (for example, "Show.S02E05.1080p.WEB.h264-GROUP").
+func ParseEpisode(name string) (int, int, bool) {
+	m := episodeRE.FindStringSubmatch(name)
+	if m == nil {
+		return 0, 0, false
+	}
+	season, _ := strconv.Atoi(m[1])
+	episode, _ := strconv.Atoi(m[2])
+	return season, episode, true
+}
+
+// ParseAirDate parses the air date from a daily release name (for example,
+// "Show.2023.10.05.720p.WEB.h264-GROUP").
+func ParseAirDate(name string) (time.Time, bool) {
+	m := dailyRE.FindStringSubmatch(name)
+	if m == nil {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("2006-01-02", m[1]+"-"+m[2]+"-"+m[3])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// ResolveEpisode resolves the episode for a TV torrent with a TVMaze id from
+// the season and episode (or air date) in its name, using the public TVMaze
+// API. Returns nil when the torrent has no TVMaze id, the name has no episode,
+// or TVMaze has no matching episode. When cl is nil, http.DefaultClient is
+// used.
+func ResolveEpisode(ctx context.Context, cl *http.Client, t Torrent) (*EpisodeInfo, error) {
+	showID := strings.TrimSpace(t.TvmazeID)
+	if showID == "" || showID == "0" {
+		return nil, nil
+	}
+	var urlstr string
+	if season, episode, ok := ParseEpisode(t.Name); ok {
+		urlstr = fmt.Sprintf("%s/shows/%s/episodebynumber?season=%d&number=%d", tvmazeURL, url.PathEscape(showID), season, episode)
+	} else if date, ok := ParseAirDate(t.Name); ok {
+		urlstr = fmt.Sprintf("%s/shows/%s/episodesbydate?date=%s", tvmazeURL, url.PathEscape(showID), date.Format("2006-01-02"))
+	} else {
+		return nil, nil
+	}
+	if cl == nil {
+		cl = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", urlstr, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := cl.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("tvmaze: %w", err)
+	}
+	defer res.Body.Close()
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case res.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("tvmaze: invalid http status %d", res.StatusCode)
+	}
+	var v tvmazeEpisode
+	if strings.Contains(urlstr, "episodesbydate") {
+		var list []tvmazeEpisode
+		if err := json.NewDecoder(res.Body).Decode(&list); err != nil {
+			return nil, fmt.Errorf("tvmaze: %w", err)
+		}
+		if len(list) == 0 {
+			return nil, nil
+		}
+		v = list[0]
+	} else if err := json.NewDecoder(res.Body).Decode(&v); err != nil {
+		return nil, fmt.Errorf("tvmaze: %w", err)
+	}
+	info := &EpisodeInfo{
+		ShowID:  showID,
+		ID:      v.ID,
+		Season:  v.Season,
+		Episode: v.Number,
+		Title:   v.Name,
+		Runtime: v.Runtime,
+		Summary: strings.TrimSpace(htmlTagRE.ReplaceAllString(v.Summary, "")),
+		URL:     v.URL,
+	}
+	if v.Airdate != "" {
+		info.AirDate, _ = time.Parse("2006-01-02", v.Airdate)
+	}
+	return info, nil
+}
+
+// EnrichTVMaze attaches TVMaze episode information to the torrents with a
+// TVMaze id. See ResolveEpisode.
+func EnrichTVMaze(ctx context.Context, cl *http.Client, v []Enriched) error {
+	for i := range v {
+		var err error
+		if v[i].Episode, err = ResolveEpisode(ctx, cl, v[i].Torrent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tvmazeEpisode is a TVMaze episode.
+type tvmazeEpisode struct {
+	ID      int    `json:"id"`
+	URL     string `json:"url"`
+	Name    string `json:"name"`
+	Season  int    `json:"season"`
+	Number  int    `json:"number"`
+	Airdate string `json:"airdate"`
+	Runtime int    `json:"runtime"`
+	Summary string `json:"summary"`
+}
+
+// tvmazeURL is the TVMaze API url.
+const tvmazeURL = "https://api.tvmaze.com"
@@ -0,0 +1,119 @@
+package tlapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// WithUnknownFieldHandler is a TL client option to set a handler called for
+// each field in a decoded json response that is not modeled by the result
+// type, with the request's url path as the endpoint and the field's dotted
+// path (for example, "torrentList.newField"). Each field is reported once
+// per response. Useful as an early warning of site api changes, without
+// the hard failures of strict decoding.
+func WithUnknownFieldHandler(f func(endpoint, field string, value json.RawMessage)) Option {
+	return func(cl *Client) {
+		cl.onUnknown = f
+	}
+}
+
+// unmarshalerType is the json.Unmarshaler type.
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// torrentType is the torrent type.
+var torrentType = reflect.TypeOf(Torrent{})
+
+// searchFacetsType is the search facets type, which is decoded leniently
+// but otherwise models its fields.
+var searchFacetsType = reflect.TypeOf(SearchFacets{})
+
+// unknownFields calls f for each json object field in buf that is not
+// modeled by the type. Types with custom json decoding (other than Torrent
+// and SearchFacets) are not inspected.
+func unknownFields(buf []byte, typ reflect.Type, path string, f func(string, json.RawMessage)) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch {
+	case typ == torrentType:
+		var m map[string]json.RawMessage
+		if json.Unmarshal(buf, &m) != nil {
+			return
+		}
+		for k, v := range m {
+			if !torrentFields[k] {
+				f(joinField(path, k), v)
+			}
+		}
+		return
+	case typ != searchFacetsType && reflect.PointerTo(typ).Implements(unmarshalerType):
+		return
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		var m map[string]json.RawMessage
+		if json.Unmarshal(buf, &m) != nil {
+			return
+		}
+		fields := make(map[string]reflect.Type)
+		jsonFields(typ, fields)
+		for k, v := range m {
+			ft, ok := fields[strings.ToLower(k)]
+			if !ok {
+				f(joinField(path, k), v)
+				continue
+			}
+			unknownFields(v, ft, joinField(path, k), f)
+		}
+	case reflect.Slice, reflect.Array:
+		var v []json.RawMessage
+		if json.Unmarshal(buf, &v) != nil {
+			return
+		}
+		for _, item := range v {
+			unknownFields(item, typ.Elem(), path, f)
+		}
+	case reflect.Map:
+		var m map[string]json.RawMessage
+		if json.Unmarshal(buf, &m) != nil {
+			return
+		}
+		for _, v := range m {
+			unknownFields(v, typ.Elem(), joinField(path, "*"), f)
+		}
+	}
+}
+
+// jsonFields adds the struct's json field names (lower cased, as json
+// matches field names case-insensitively) and types to fields, including
+// the fields of embedded structs.
+func jsonFields(typ reflect.Type, fields map[string]reflect.Type) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			jsonFields(field.Type, fields)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[strings.ToLower(name)] = field.Type
+	}
+}
+
+// joinField joins the field path and name.
+func joinField(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
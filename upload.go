@@ -0,0 +1,109 @@
+package tlapi
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// UploadDraft is a torrent upload draft.
+type UploadDraft struct {
+	// Name is the release name. When empty, the torrent's name is used.
+	Name     string
+	Category int
+	// Torrent is the torrent's metainfo (.torrent file contents).
+	Torrent []byte
+	// NFO is the release's NFO (optional).
+	NFO         []byte
+	Description string
+}
+
+// Upload draft limits.
+var (
+	minPieceLength int64 = 16 << 10
+	maxPieceLength int64 = 64 << 20
+	maxNFOSize           = 100 << 10
+	// bannedNameChars are characters not allowed in release names.
+	bannedNameChars = `/\:*?"<>|`
+)
+
+// DraftError is an upload draft error.
+type DraftError struct {
+	Field string
+	Err   error
+}
+
+// Error satisfies the error interface.
+func (err *DraftError) Error() string {
+	return fmt.Sprintf("%s: %v", err.Field, err.Err)
+}
+
+// Unwrap returns the underlying error.
+func (err *DraftError) Unwrap() error {
+	return err.Err
+}
+
+// DraftErrors are upload draft errors.
+type DraftErrors []*DraftError
+
+// Error satisfies the error interface.
+func (errs DraftErrors) Error() string {
+	v := make([]string, len(errs))
+	for i, err := range errs {
+		v[i] = err.Error()
+	}
+	return fmt.Sprintf("%d upload draft problem(s): %s", len(errs), strings.Join(v, "; "))
+}
+
+// Validate validates the upload draft locally, before submitting it,
+// returning all problems found as DraftErrors, so they can be fixed in one
+// pass. Checks that the category is a known category, the torrent parses
+// and has a sane piece size, the NFO is within the size limit, and the name
+// does not contain banned characters.
+func (d *UploadDraft) Validate() error {
+	var errs DraftErrors
+	add := func(field, format string, v ...interface{}) {
+		errs = append(errs, &DraftError{Field: field, Err: fmt.Errorf(format, v...)})
+	}
+	switch _, ok := CategoryByID(d.Category); {
+	case d.Category == 0:
+		add("category", "required")
+	case !ok:
+		add("category", "unknown category %d", d.Category)
+	}
+	name := d.Name
+	if len(d.Torrent) == 0 {
+		add("torrent", "required")
+	} else if m, err := ParseMetainfo(d.Torrent); err != nil {
+		errs = append(errs, &DraftError{Field: "torrent", Err: err})
+	} else {
+		if m.PieceLength < minPieceLength || m.PieceLength > maxPieceLength || m.PieceLength&(m.PieceLength-1) != 0 {
+			add("torrent", "invalid piece size %d (must be a power of 2 from %d to %d)", m.PieceLength, minPieceLength, maxPieceLength)
+		}
+		if m.Length == 0 {
+			add("torrent", "empty torrent")
+		}
+		if name == "" {
+			name = m.Name
+		}
+	}
+	if len(d.NFO) > maxNFOSize {
+		add("nfo", "size %d exceeds %d bytes", len(d.NFO), maxNFOSize)
+	}
+	switch {
+	case strings.TrimSpace(name) == "" && len(d.Torrent) != 0:
+		// without a torrent, the missing torrent is already reported
+		add("name", "required")
+	case strings.TrimSpace(name) != name:
+		add("name", "leading or trailing whitespace")
+	}
+	if i := strings.IndexFunc(name, func(r rune) bool {
+		return strings.ContainsRune(bannedNameChars, r) || unicode.IsControl(r)
+	}); i >= 0 {
+		add("name", "banned character %q", []rune(name[i:])[0])
+	}
+	if len(errs) != 0 {
+		return errs
+	}
+	return nil
+}
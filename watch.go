@@ -0,0 +1,125 @@
+package tlapi
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// WatchEventType is a torrent watch event type.
+type WatchEventType int
+
+// Watch event types.
+const (
+	// WatchSeeders is the seeder count change event.
+	WatchSeeders WatchEventType = iota
+	// WatchComments is the new comments event.
+	WatchComments
+	// WatchDeleted is the torrent deleted event.
+	WatchDeleted
+)
+
+// String satisfies the fmt.Stringer interface.
+func (typ WatchEventType) String() string {
+	switch typ {
+	case WatchSeeders:
+		return "seeders"
+	case WatchComments:
+		return "comments"
+	case WatchDeleted:
+		return "deleted"
+	}
+	return "WatchEventType(" + strconv.Itoa(int(typ)) + ")"
+}
+
+// WatchEvent is a torrent watch event.
+type WatchEvent struct {
+	Type WatchEventType `json:"type"`
+	ID   int            `json:"id"`
+	// Torrent is the torrent's current metadata (for deleted torrents, the
+	// last seen metadata).
+	Torrent Torrent `json:"torrent"`
+	// Prev is the torrent's previously seen metadata.
+	Prev Torrent `json:"prev"`
+}
+
+// WatchTorrents watches the torrent ids every interval (at least 1 minute),
+// calling fn with an event for each seeder count change, new comments, or
+// deletion, until the context is done or all the torrents have been
+// deleted. The torrents are looked up as with Torrents, so each poll makes
+// two requests per torrent. Changes are relative to the first poll. Torrents
+// missing from the lookup's search are checked with Exists before being
+// reported deleted, and failed lookups are logged and retried at the next
+// interval.
+func (cl *Client) WatchTorrents(ctx context.Context, ids []int, interval time.Duration, fn func(WatchEvent)) error {
+	if interval < minPollInterval {
+		interval = minPollInterval
+	}
+	seen := make(map[int]Torrent, len(ids))
+	watching := append([]int(nil), ids...)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for len(watching) != 0 {
+		m, err := cl.Torrents(ctx, watching...)
+		deleted := make(map[int]bool)
+		var errs IDErrors
+		switch {
+		case errors.As(err, &errs):
+			for _, err := range errs {
+				if errors.Is(err, ErrNotFound) || cl.confirmDeleted(ctx, err) {
+					deleted[err.ID] = true
+				} else {
+					cl.logf("watch: %v", err)
+				}
+			}
+		case err != nil && ctx.Err() == nil:
+			cl.logf("watch: %v", err)
+		}
+		v := watching[:0]
+		for _, id := range watching {
+			t, ok := m[id]
+			prev, primed := seen[id]
+			switch {
+			case ok:
+				seen[id] = t
+			case deleted[id]:
+				fn(WatchEvent{Type: WatchDeleted, ID: id, Torrent: prev, Prev: prev})
+				continue
+			}
+			v = append(v, id)
+			if !ok || !primed {
+				continue
+			}
+			if t.Seeders != prev.Seeders {
+				fn(WatchEvent{Type: WatchSeeders, ID: id, Torrent: t, Prev: prev})
+			}
+			if t.NumComments > prev.NumComments {
+				fn(WatchEvent{Type: WatchComments, ID: id, Torrent: t, Prev: prev})
+			}
+		}
+		watching = v
+		if len(watching) == 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// confirmDeleted determines if a torrent missing from its lookup's search, or
+// with a details page without a name, has been deleted.
+func (cl *Client) confirmDeleted(ctx context.Context, err *IDError) bool {
+	if !errors.Is(err, ErrNotListed) && !errors.Is(err, errNoName) {
+		return false
+	}
+	ok, existsErr := cl.Exists(ctx, err.ID)
+	if existsErr != nil {
+		cl.logf("watch: %v", existsErr)
+	}
+	return existsErr == nil && !ok
+}
@@ -0,0 +1,170 @@
+package tlapi
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Watermark is a search sync watermark: the latest added timestamp seen,
+// and the ids of the torrents seen with that timestamp.
+type Watermark struct {
+	Time time.Time `json:"time"`
+	IDs  []int     `json:"ids,omitempty"`
+}
+
+// WatermarkStore is the interface for persisting watermarks (see the store
+// subpackage).
+type WatermarkStore interface {
+	PutWatermark(key string, w Watermark) error
+	Watermark(key string) (Watermark, bool)
+}
+
+// Syncer returns only new torrents for search requests on each sync, using
+// per-query watermarks. A syncer is safe for concurrent use.
+type Syncer struct {
+	// Client is the client used to search.
+	Client *Client
+	// Store is the store watermarks are persisted to. When nil, watermarks
+	// are only kept in memory.
+	Store WatermarkStore
+
+	mu         sync.Mutex
+	watermarks map[string]Watermark
+}
+
+// Sync returns the torrents for the search request that are new since the
+// last sync of the same query, newest first, and advances the query's
+// watermark. Torrents added in the same second as the watermark are
+// returned once. The first sync of a query returns all its results.
+//
+// When an error occurs, the torrents retrieved before the error are
+// returned along with it, and the watermark is not advanced.
+func (s *Syncer) Sync(ctx context.Context, req *SearchRequest) ([]Torrent, error) {
+	key := SyncKey(req)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.watermark(key)
+	r := req.WithOrderBy(OrderByAdded).WithOrder(OrderDesc)
+	if ok {
+		r = r.Since(w.Time.Add(-time.Nanosecond))
+	}
+	seen := make(map[int]bool, len(w.IDs))
+	for _, id := range w.IDs {
+		seen[id] = true
+	}
+	var torrents []Torrent
+	for r.Next(ctx, s.Client) {
+		if t := r.Cur(); !seen[t.ID] {
+			torrents = append(torrents, t)
+		}
+	}
+	if err := r.Err(); err != nil {
+		return torrents, err
+	}
+	if len(torrents) == 0 {
+		return nil, nil
+	}
+	return torrents, s.putWatermark(key, w.advance(torrents))
+}
+
+// advance returns the watermark advanced past the torrents.
+func (w Watermark) advance(torrents []Torrent) Watermark {
+	if len(torrents) == 0 {
+		return w
+	}
+	next := Watermark{Time: torrents[0].AddedTimestamp}
+	for _, t := range torrents {
+		if t.AddedTimestamp.After(next.Time) {
+			next = Watermark{Time: t.AddedTimestamp}
+		}
+		if t.AddedTimestamp.Equal(next.Time) {
+			next.IDs = append(next.IDs, t.ID)
+		}
+	}
+	if next.Time.Equal(w.Time) {
+		next.IDs = append(next.IDs, w.IDs...)
+	}
+	return next
+}
+
+// minPollInterval is the minimum poll interval (a var for testing).
+var minPollInterval = time.Minute
+
+// PollNew polls the site for newly added torrents every interval (at least
+// 1 minute), calling fn for each new torrent, oldest first, until the
+// context is done. Torrents listed when polling starts (or, when none are
+// listed, added before polling starts) are not passed to fn. Failed polls
+// are logged and retried at the next interval, as requests are paced by the
+// client's limiter and the search's next delay.
+func (cl *Client) PollNew(ctx context.Context, interval time.Duration, fn func(Torrent)) error {
+	if interval < minPollInterval {
+		interval = minPollInterval
+	}
+	req, s := Search(), &Syncer{Client: cl}
+	key, primed := SyncKey(req), false
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if !primed {
+			res, err := req.WithOrderBy(OrderByAdded).WithOrder(OrderDesc).Do(ctx, cl)
+			if err == nil {
+				w := Watermark{}.advance(res.TorrentList)
+				if len(res.TorrentList) == 0 {
+					// without a listed torrent, start from now so later
+					// polls do not walk every page
+					w.Time = time.Now().Truncate(time.Second)
+				}
+				primed, err = true, s.putWatermark(key, w)
+			}
+			if err != nil {
+				cl.logf("poll: %v", err)
+			}
+		} else {
+			torrents, err := s.Sync(ctx, req)
+			if err != nil {
+				cl.logf("poll: %v", err)
+			} else {
+				for i := len(torrents) - 1; i >= 0; i-- {
+					fn(torrents[i])
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// watermark returns the watermark for the key.
+func (s *Syncer) watermark(key string) (Watermark, bool) {
+	if s.Store != nil {
+		return s.Store.Watermark(key)
+	}
+	w, ok := s.watermarks[key]
+	return w, ok
+}
+
+// putWatermark sets the watermark for the key.
+func (s *Syncer) putWatermark(key string, w Watermark) error {
+	if s.Store != nil {
+		return s.Store.PutWatermark(key, w)
+	}
+	if s.watermarks == nil {
+		s.watermarks = make(map[string]Watermark)
+	}
+	s.watermarks[key] = w
+	return nil
+}
+
+// SyncKey returns the watermark key for the search request's query
+// (categories, facets, query, and added filter).
+func SyncKey(req *SearchRequest) string {
+	params := newSearchParams(req)
+	params.OrderBy, params.Order, params.Page = "", "", 0
+	buf, _ := json.Marshal(params)
+	return string(buf)
+}
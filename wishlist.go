@@ -0,0 +1,159 @@
+package tlapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// wishlistWorkers is the number of concurrent searches made by
+// Wishlist.Check.
+const wishlistWorkers = 2
+
+// WishlistItem is a wanted item.
+type WishlistItem struct {
+	Title  string `json:"title"`
+	Year   int    `json:"year,omitempty"`
+	ImdbID string `json:"imdbID,omitempty"`
+	// Categories are the categories to search (all when empty).
+	Categories []int `json:"categories,omitempty"`
+	// Profile is the desired quality profile. Any quality matches when the
+	// profile has no qualities.
+	Profile Profile `json:"profile"`
+	// Seen are the ids of the torrents already reported for the item.
+	Seen []int `json:"seen,omitempty"`
+}
+
+// request returns the search request for the item, searching by IMDb id
+// when available, or by the title and year otherwise.
+func (item WishlistItem) request() *SearchRequest {
+	if id := NormalizeIMDbID(item.ImdbID); id != "" {
+		return Search(id).WithCategories(item.Categories...)
+	}
+	req := Search().WithExactPhrase(item.Title)
+	if item.Year != 0 {
+		req.Query = append(req.Query, strconv.Itoa(item.Year))
+	}
+	return req.WithCategories(item.Categories...)
+}
+
+// Match returns true when the torrent is the wanted item, by IMDb id when
+// both have one, or by normalized title and year otherwise.
+func (item WishlistItem) Match(t Torrent) bool {
+	if a, b := NormalizeIMDbID(item.ImdbID), NormalizeIMDbID(t.ImdbID); a != "" && b != "" {
+		return a == b
+	}
+	r := ParseRelease(t.Name)
+	return NormalizeTitle(r.Title) == NormalizeTitle(item.Title) &&
+		(item.Year == 0 || r.Year == 0 || r.Year == item.Year)
+}
+
+// WishlistMatch is a newly available torrent for a wanted item.
+type WishlistMatch struct {
+	Item    WishlistItem
+	Torrent Torrent
+	// Quality is the index of the matched quality in the item's profile (0
+	// when the profile has no qualities).
+	Quality int
+}
+
+// Wishlist is a list of wanted items, persisted as json (see LoadWishlist
+// and Save). A wishlist is safe for concurrent use.
+type Wishlist struct {
+	mu    sync.Mutex
+	items []WishlistItem
+}
+
+// NewWishlist creates a wishlist of the items.
+func NewWishlist(items ...WishlistItem) *Wishlist {
+	return &Wishlist{
+		items: items,
+	}
+}
+
+// LoadWishlist reads a wishlist written by Save from the reader.
+func LoadWishlist(r io.Reader) (*Wishlist, error) {
+	var items []WishlistItem
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, err
+	}
+	return NewWishlist(items...), nil
+}
+
+// Save writes the wishlist to the writer as json.
+func (w *Wishlist) Save(wr io.Writer) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	enc := json.NewEncoder(wr)
+	enc.SetIndent("", "  ")
+	return enc.Encode(w.items)
+}
+
+// Add adds the item to the wishlist.
+func (w *Wishlist) Add(item WishlistItem) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.items = append(w.items, item)
+}
+
+// Items returns the wishlist's items.
+func (w *Wishlist) Items() []WishlistItem {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]WishlistItem(nil), w.items...)
+}
+
+// Check searches for each wanted item (see SearchMany), returning the
+// matching torrents allowed by the item's profile that were not reported by
+// a previous check, ordered by item, then by profile quality and seeders.
+// Reported torrents are marked as seen; Save the wishlist to persist them.
+//
+// When one or more searches fail, the matches for the successful searches
+// are returned along with a QueryErrors error.
+func (w *Wishlist) Check(ctx context.Context, cl *Client) ([]WishlistMatch, error) {
+	items := w.Items()
+	reqs := make([]*SearchRequest, len(items))
+	for i, item := range items {
+		reqs[i] = item.request()
+	}
+	results, err := SearchMany(ctx, cl, reqs, wishlistWorkers)
+	var matches []WishlistMatch
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, item := range items {
+		seen := make(map[int]bool, len(item.Seen))
+		for _, id := range item.Seen {
+			seen[id] = true
+		}
+		var v []WishlistMatch
+		for _, t := range results[i] {
+			if seen[t.ID] || !item.Match(t) {
+				continue
+			}
+			q := 0
+			if len(item.Profile.Qualities) != 0 {
+				if q = item.Profile.Quality(t); q == -1 {
+					continue
+				}
+			}
+			seen[t.ID] = true
+			item.Seen = append(item.Seen, t.ID)
+			v = append(v, WishlistMatch{Item: item, Torrent: t, Quality: q})
+		}
+		sort.SliceStable(v, func(i, j int) bool {
+			if v[i].Quality != v[j].Quality {
+				return v[i].Quality < v[j].Quality
+			}
+			return v[i].Torrent.Seeders > v[j].Torrent.Seeders
+		})
+		matches = append(matches, v...)
+		// items may have changed during the check
+		if i < len(w.items) && w.items[i].Title == item.Title && w.items[i].ImdbID == item.ImdbID {
+			w.items[i].Seen = item.Seen
+		}
+	}
+	return matches, err
+}